@@ -25,11 +25,23 @@ func (er *ExternalRegistry) GetService(serviceName string) (protomanager.Service
     return protomanager.ServiceMetadata{}, nil
 }
 
+func (er *ExternalRegistry) UnregisterService(serviceName string) error {
+    // Implementation...
+    return nil
+}
+
+func (er *ExternalRegistry) ListServices() (map[string]protomanager.ServiceMetadata, error) {
+    // Implementation...
+    return map[string]protomanager.ServiceMetadata{}, nil
+}
+
 func (er *ExternalRegistry) OnProtoManagerEvent(event protomanager.Event) {
     switch event.Type {
     case "ServiceRegistered":
-        metadata := event.Payload.(protomanager.ServiceMetadata)
-        // Handle the event...
+        if metadata, ok := event.Payload.(protomanager.ServiceMetadata); ok {
+            // Handle the event...
+            _ = metadata
+        }
     }
 }
 
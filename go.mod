@@ -3,5 +3,11 @@ module github.com/Cdaprod/protomanager
 go 1.20
 
 require (
+	github.com/bufbuild/protocompile v0.10.0
 	github.com/sirupsen/logrus v1.8.1
-)
\ No newline at end of file
+	golang.org/x/sys v0.30.0
+	google.golang.org/protobuf v1.34.2
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sync v0.6.0 // indirect
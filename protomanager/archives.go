@@ -0,0 +1,74 @@
+// protomanager/archives.go
+package protomanager
+
+import (
+    "archive/tar"
+    "archive/zip"
+    "bytes"
+    "compress/gzip"
+    "path"
+    "time"
+)
+
+// archiveTime is the modification time of every archive entry, so the same
+// inputs always produce byte-identical artifacts.
+var archiveTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// zipArchive returns a zip of entries, sorted by name, below prefix.
+func zipArchive(prefix string, entries []archiveEntry) ([]byte, error) {
+    sortEntries(entries)
+    var buf bytes.Buffer
+    w := zip.NewWriter(&buf)
+    for _, entry := range entries {
+        header := &zip.FileHeader{
+            Name:     path.Join(prefix, entry.Name),
+            Method:   zip.Deflate,
+            Modified: archiveTime,
+        }
+        header.SetMode(0644)
+        f, err := w.CreateHeader(header)
+        if err != nil {
+            return nil, err
+        }
+        if _, err := f.Write(entry.Data); err != nil {
+            return nil, err
+        }
+    }
+    if err := w.Close(); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+// tarGzArchive returns a gzipped tar of entries, sorted by name, below prefix.
+func tarGzArchive(prefix string, entries []archiveEntry) ([]byte, error) {
+    sortEntries(entries)
+    var buf bytes.Buffer
+    gz, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+    if err != nil {
+        return nil, err
+    }
+    gz.ModTime = archiveTime
+    w := tar.NewWriter(gz)
+    for _, entry := range entries {
+        header := &tar.Header{
+            Name:    path.Join(prefix, entry.Name),
+            Mode:    0644,
+            Size:    int64(len(entry.Data)),
+            ModTime: archiveTime,
+        }
+        if err := w.WriteHeader(header); err != nil {
+            return nil, err
+        }
+        if _, err := w.Write(entry.Data); err != nil {
+            return nil, err
+        }
+    }
+    if err := w.Close(); err != nil {
+        return nil, err
+    }
+    if err := gz.Close(); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
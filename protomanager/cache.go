@@ -0,0 +1,242 @@
+// protomanager/cache.go
+package protomanager
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+)
+
+// generationCacheFile is the cache manifest written at the root of OutputDir.
+// Keeping it next to the generated code means deleting OutputDir also
+// invalidates the cache, and dry runs never touch the real one.
+const generationCacheFile = ".protomanager-cache.json"
+
+// generationCache maps an OutputTarget key, e.g. "billing/go", to the
+// fingerprint of the inputs it was last generated from.
+type generationCache struct {
+    Entries map[string]string `json:"entries"`
+}
+
+// GenerationCachePath returns the location of the generation cache manifest.
+func (pm *ProtoManager) GenerationCachePath() string {
+    return filepath.Join(pm.OutputDir, generationCacheFile)
+}
+
+// GenerateCached runs req through the configured Generator unless the
+// fingerprint of its inputs matches the one recorded for target, in which case
+// generation is skipped. Setting Force bypasses the cache. After generating it
+// writes fakes and scaffolds go.mod for Go targets, runs the post-generation
+// hooks of target, writes its output manifest and cleans up files the
+// previous run produced but this one did not. It reports whether code was
+// generated.
+func (pm *ProtoManager) GenerateCached(ctx context.Context, target OutputTarget, req GenerateRequest) (bool, error) {
+    key := target.key()
+    fingerprint, err := pm.fingerprint(target, req)
+    if err != nil {
+        return false, fmt.Errorf("failed to fingerprint '%s': %w", key, err)
+    }
+
+    if !pm.Force && pm.cachedFingerprint(key) == fingerprint && pm.manifestFilesExist(target) {
+        pm.Logger.Infof("Generation cache hit for '%s'; skipping", key)
+        pm.emitEvent(Event{Type: "GenerationCacheHit", Message: fmt.Sprintf("Generation cache hit for '%s'", key)})
+        return false, nil
+    }
+    pm.Logger.Debugf("Generation cache miss for '%s'", key)
+    pm.emitEvent(Event{Type: "GenerationCacheMiss", Message: fmt.Sprintf("Generation cache miss for '%s'", key)})
+
+    previous, err := pm.ReadOutputManifest(target.Package, target.Language)
+    if err != nil {
+        pm.Logger.Warnf("Ignoring unreadable manifest for '%s': %v", key, err)
+    }
+
+    files, err := pm.generateStaged(ctx, req)
+    if err != nil {
+        return false, err
+    }
+    fakes, err := pm.generateFakes(ctx, target, req, files)
+    if err != nil {
+        return false, fmt.Errorf("failed to generate fakes for '%s': %w", key, err)
+    }
+    files = append(files, fakes...)
+    goModFiles, err := pm.scaffoldGoModule(ctx, target)
+    if err != nil {
+        return false, fmt.Errorf("failed to scaffold go.mod for '%s': %w", key, err)
+    }
+    files = append(files, goModFiles...)
+    if err := pm.runHooks(ctx, target); err != nil {
+        return false, err
+    }
+    current, err := pm.writeOutputManifest(ctx, target, req, files)
+    if err != nil {
+        return false, fmt.Errorf("failed to write manifest for '%s': %w", key, err)
+    }
+
+    outDirs := make([]string, 0, len(req.Plugins))
+    for _, plugin := range req.Plugins {
+        outDirs = append(outDirs, plugin.OutDir)
+    }
+    if _, err := pm.CleanupStale(target, previous, current, outDirs); err != nil {
+        return false, fmt.Errorf("failed to clean up stale files for '%s': %w", key, err)
+    }
+    if err := pm.storeFingerprint(key, fingerprint); err != nil {
+        pm.Logger.Warnf("Failed to update generation cache: %v", err)
+    }
+    return true, nil
+}
+
+// fingerprint hashes everything that influences the output of req: the
+// contents of its proto files and their transitive imports, the plugins with
+// their options and executables, the generator backend and the version,
+// domain and post-generation hooks of target.
+func (pm *ProtoManager) fingerprint(target OutputTarget, req GenerateRequest) (string, error) {
+    resolved, err := (&ImportResolver{IncludePaths: req.IncludePaths}).Resolve(req.Files)
+    if err != nil {
+        return "", err
+    }
+
+    h := sha256.New()
+    backend := pm.backendName()
+    fmt.Fprintf(h, "generator %s\n", backend)
+    if backend == "protoc" {
+        fmt.Fprintf(h, "protoc %s\n", executableDigest(pm.protocBinary()))
+    }
+    fmt.Fprintf(h, "version %s\n", target.Version)
+    fmt.Fprintf(h, "domain %s\n", target.Domain)
+
+    for _, file := range resolved {
+        digest, err := fileDigest(file.Path)
+        if err != nil {
+            return "", err
+        }
+        fmt.Fprintf(h, "file %s %s\n", file.Name, digest)
+    }
+
+    // Output directories are hashed relative to OutputDir, which is a fresh
+    // staging copy on every run
+    for _, plugin := range req.Plugins {
+        fmt.Fprintf(h, "plugin %s %s %s %s\n", plugin.Name, strings.Join(plugin.Options, ","), pm.relativeOutputPath(plugin.OutDir), executableDigest(pm.pluginBinary(plugin)))
+    }
+
+    if goLanguages[target.Language] && pm.Config.GoModule.Mode != "" {
+        gm := pm.Config.GoModule
+        fmt.Fprintf(h, "go_module %s %s %s %s %s %t\n", gm.Mode, gm.Path, gm.GoVersion, pm.Config.Module, target.Domain, gm.Tidy)
+        for _, mod := range sortedKeys(gm.Require) {
+            fmt.Fprintf(h, "require %s %s\n", mod, gm.Require[mod])
+        }
+    }
+
+    if goLanguages[target.Language] {
+        fmt.Fprintf(h, "fakes %t\n", pm.Config.Fakes.Enabled)
+    }
+
+    for _, hook := range target.Hooks {
+        fmt.Fprintf(h, "hook %s %s %s\n", hook.Name, hook.Builtin, strings.Join(hook.Command, " "))
+    }
+
+    fmt.Fprintf(h, "descriptor_set %s %t %t\n", pm.relativeOutputPath(req.DescriptorSetOut), req.IncludeImports, req.IncludeSourceInfo)
+    return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// relativeOutputPath returns path relative to OutputDir, or path itself when
+// it lies outside OutputDir.
+func (pm *ProtoManager) relativeOutputPath(path string) string {
+    if path == "" {
+        return ""
+    }
+    rel, err := filepath.Rel(pm.OutputDir, path)
+    if err != nil || strings.HasPrefix(rel, "..") {
+        return path
+    }
+    return filepath.ToSlash(rel)
+}
+
+// cachedFingerprint returns the fingerprint recorded for key, or "".
+func (pm *ProtoManager) cachedFingerprint(key string) string {
+    pm.cacheMu.Lock()
+    defer pm.cacheMu.Unlock()
+
+    cache, err := pm.readGenerationCache()
+    if err != nil {
+        pm.Logger.Warnf("Ignoring unreadable generation cache: %v", err)
+        return ""
+    }
+    return cache.Entries[key]
+}
+
+// storeFingerprint records fingerprint for key in the cache manifest.
+func (pm *ProtoManager) storeFingerprint(key, fingerprint string) error {
+    pm.cacheMu.Lock()
+    defer pm.cacheMu.Unlock()
+
+    cache, err := pm.readGenerationCache()
+    if err != nil {
+        cache = &generationCache{Entries: map[string]string{}}
+    }
+    cache.Entries[key] = fingerprint
+
+    data, err := json.MarshalIndent(cache, "", "  ")
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(pm.OutputDir, os.ModePerm); err != nil {
+        return err
+    }
+    return writeFileAtomic(pm.GenerationCachePath(), append(data, '\n'), 0644)
+}
+
+// readGenerationCache loads the cache manifest; a missing file yields an empty cache.
+func (pm *ProtoManager) readGenerationCache() (*generationCache, error) {
+    cache := &generationCache{Entries: map[string]string{}}
+    data, err := os.ReadFile(pm.GenerationCachePath())
+    if os.IsNotExist(err) {
+        return cache, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(data, cache); err != nil {
+        return nil, err
+    }
+    if cache.Entries == nil {
+        cache.Entries = map[string]string{}
+    }
+    return cache, nil
+}
+
+// fileDigest returns the hex SHA-256 of the file at path.
+func fileDigest(path string) (string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return "", err
+    }
+    defer f.Close()
+
+    h := sha256.New()
+    if _, err := io.Copy(h, f); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// executableDigest identifies the executable binary resolves to by content, so
+// upgrading a plugin invalidates the cache. Missing executables hash to
+// "missing" and fail later during generation.
+func executableDigest(binary string) string {
+    path, err := exec.LookPath(binary)
+    if err != nil {
+        return "missing"
+    }
+    digest, err := fileDigest(path)
+    if err != nil {
+        return "missing"
+    }
+    return digest
+}
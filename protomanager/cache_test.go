@@ -0,0 +1,68 @@
+// protomanager/cache_test.go
+package protomanager
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/types/pluginpb"
+)
+
+// TestGenerateCached runs one target repeatedly, changing one input between
+// runs, and checks which runs hit the generation cache.
+func TestGenerateCached(t *testing.T) {
+    pm := newTestProtoManager(t)
+    pm.Config.Generator = "native"
+
+    protoDir := pm.ProtoPath("billing")
+    file := filepath.Join(protoDir, "billing.proto")
+    if err := os.MkdirAll(protoDir, os.ModePerm); err != nil {
+        t.Fatal(err)
+    }
+    writeProto := func(src string) {
+        if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    writeProto("syntax = \"proto3\";\npackage billing;\nmessage Invoice {}\n")
+
+    outDir := filepath.Join(pm.OutputDir, "billing", "python")
+    binary := fakePlugin(t, &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{
+        {Name: proto.String("billing_pb2.py"), Content: proto.String("# generated\n")},
+    }})
+    target := OutputTarget{Package: "billing", Language: "python", Version: "v1", Domain: "pay", Dir: outDir}
+    req := GenerateRequest{
+        IncludePaths: pm.IncludePaths(protoDir),
+        Files:        []string{file},
+        Plugins:      []PluginInvocation{{Name: "fake", Binary: binary, OutDir: outDir}},
+    }
+
+    steps := []struct {
+        name      string
+        change    func()
+        generated bool
+    }{
+        {"first run", func() {}, true},
+        {"unchanged", func() {}, false},
+        {"version", func() { target.Version = "v2" }, true},
+        {"domain", func() { target.Domain = "finance" }, true},
+        {"unchanged after changes", func() {}, false},
+        {"proto contents", func() { writeProto("syntax = \"proto3\";\npackage billing;\nmessage Invoice { string id = 1; }\n") }, true},
+        {"plugin options", func() { req.Plugins[0].Options = []string{"paths=source_relative"} }, true},
+        {"generated file deleted", func() { os.Remove(filepath.Join(outDir, "billing_pb2.py")) }, true},
+        {"force", func() { pm.Force = true }, true},
+    }
+    for _, step := range steps {
+        step.change()
+        generated, err := pm.GenerateCached(context.Background(), target, req)
+        if err != nil {
+            t.Fatalf("%s: %v", step.name, err)
+        }
+        if generated != step.generated {
+            t.Errorf("%s: generated = %t, want %t", step.name, generated, step.generated)
+        }
+    }
+}
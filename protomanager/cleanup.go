@@ -0,0 +1,238 @@
+// protomanager/cleanup.go
+package protomanager
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// Cleanup modes for stale generated files.
+const (
+    // CleanupSafe removes stale files only while they are unmodified copies
+    // of what protomanager generated, and reports everything else.
+    CleanupSafe = "safe"
+    // CleanupDelete also removes stale files that were edited by hand and
+    // untracked files in output directories protomanager owns.
+    CleanupDelete = "delete"
+    // CleanupReport only reports stale files.
+    CleanupReport = "report"
+    // CleanupOff disables stale file detection.
+    CleanupOff = "off"
+)
+
+// CleanupConfig configures how stale generated files are handled.
+type CleanupConfig struct {
+    Mode string `yaml:"mode"` // safe (default), delete, report or off
+}
+
+// StaleFile is a file in OutputDir that the latest generation run did not produce.
+type StaleFile struct {
+    Path    string // Relative to OutputDir
+    Reason  string
+    Removed bool
+}
+
+// String implements fmt.Stringer.
+func (f StaleFile) String() string {
+    action := "kept"
+    if f.Removed {
+        action = "removed"
+    }
+    return fmt.Sprintf("%s %s (%s)", action, f.Path, f.Reason)
+}
+
+// cleanupMode returns the configured cleanup mode, defaulting to CleanupSafe.
+func (pm *ProtoManager) cleanupMode() (string, error) {
+    switch mode := pm.Config.Cleanup.Mode; mode {
+    case "":
+        return CleanupSafe, nil
+    case CleanupSafe, CleanupDelete, CleanupReport, CleanupOff:
+        return mode, nil
+    default:
+        return "", fmt.Errorf("unknown cleanup mode '%s'; use safe, delete, report or off", mode)
+    }
+}
+
+// CleanupStale removes or reports files that previous generation runs of
+// target produced but the current run, described by current, did not. Files
+// are owned through the output manifests: a file is only ever removed when a
+// manifest records protomanager generating it, unless the mode is CleanupDelete.
+// A nil current treats every previously generated file as stale.
+func (pm *ProtoManager) CleanupStale(target OutputTarget, previous, current *OutputManifest, outDirs []string) ([]StaleFile, error) {
+    mode, err := pm.cleanupMode()
+    if err != nil || mode == CleanupOff {
+        return nil, err
+    }
+
+    keep := map[string]bool{}
+    if current != nil {
+        for _, file := range current.Files {
+            keep[file.Path] = true
+        }
+    }
+
+    var stale []StaleFile
+    if previous != nil {
+        for _, file := range previous.Files {
+            if keep[file.Path] {
+                continue
+            }
+            digest, err := fileDigest(pm.outputPath(file.Path))
+            switch {
+            case os.IsNotExist(err):
+                continue
+            case err != nil:
+                return stale, err
+            case digest == file.SHA256:
+                stale = append(stale, StaleFile{Path: file.Path, Reason: "no longer generated"})
+            default:
+                stale = append(stale, StaleFile{Path: file.Path, Reason: "no longer generated; modified since generation"})
+            }
+            keep[file.Path] = true
+        }
+    }
+
+    // Files in the run's own output directories that no manifest accounts for
+    owned, err := pm.manifestOwnedFiles()
+    if err != nil {
+        return stale, err
+    }
+    for _, dir := range outDirs {
+        if filepath.Clean(dir) == filepath.Clean(pm.OutputDir) {
+            continue // OutputDir itself is shared with every other package
+        }
+        files, err := listFiles(dir)
+        if err != nil {
+            return stale, err
+        }
+        for _, rel := range sortedKeys(files) {
+            path, err := filepath.Rel(pm.OutputDir, filepath.Join(dir, filepath.FromSlash(rel)))
+            if err != nil {
+                return stale, err
+            }
+            path = filepath.ToSlash(path)
+            if keep[path] || owned[path] {
+                continue
+            }
+            stale = append(stale, StaleFile{Path: path, Reason: "not generated by protomanager"})
+            keep[path] = true
+        }
+    }
+
+    for i, file := range stale {
+        removable := mode == CleanupDelete || (mode == CleanupSafe && file.Reason == "no longer generated")
+        if !removable {
+            continue
+        }
+        if err := pm.removeOutputFile(file.Path); err != nil {
+            return stale, err
+        }
+        stale[i].Removed = true
+    }
+
+    pm.reportStale(target, stale)
+    return stale, nil
+}
+
+// CleanupPackage removes or reports every file generated for a package, for
+// use once the package has been unregistered, and deletes its manifests.
+func (pm *ProtoManager) CleanupPackage(packageName string) ([]StaleFile, error) {
+    dir := filepath.Join(pm.OutputDir, manifestsDir, packageName)
+    entries, err := os.ReadDir(dir)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var stale []StaleFile
+    for _, entry := range entries {
+        language := strings.TrimSuffix(entry.Name(), ".json")
+        previous, err := pm.ReadOutputManifest(packageName, language)
+        if err != nil || previous == nil {
+            return stale, err
+        }
+        if err := os.Remove(pm.OutputManifestPath(packageName, language)); err != nil {
+            return stale, err
+        }
+        files, err := pm.CleanupStale(OutputTarget{Package: packageName, Language: language}, previous, nil, nil)
+        stale = append(stale, files...)
+        if err != nil {
+            return stale, err
+        }
+    }
+    removeEmptyDirs(dir, pm.OutputDir)
+    return stale, nil
+}
+
+// reportStale logs and emits events for the stale files of target.
+func (pm *ProtoManager) reportStale(target OutputTarget, stale []StaleFile) {
+    var removed, kept int
+    for _, file := range stale {
+        if file.Removed {
+            removed++
+        } else {
+            kept++
+            pm.Logger.Warnf("Stale file '%s' kept: %s", file.Path, file.Reason)
+        }
+    }
+    if removed > 0 {
+        pm.Logger.Infof("Removed %d stale file(s) for '%s'", removed, target.key())
+        pm.emitEvent(Event{Type: "StaleFilesRemoved", Message: fmt.Sprintf("Removed %d stale file(s) for '%s'", removed, target.key())})
+    }
+    if kept > 0 {
+        pm.emitEvent(Event{Type: "StaleFilesDetected", Message: fmt.Sprintf("%d stale file(s) for '%s' were not removed", kept, target.key())})
+    }
+}
+
+// manifestOwnedFiles returns the files listed in every output manifest.
+func (pm *ProtoManager) manifestOwnedFiles() (map[string]bool, error) {
+    manifests, err := listFiles(filepath.Join(pm.OutputDir, manifestsDir))
+    if err != nil {
+        return nil, err
+    }
+    owned := map[string]bool{}
+    for rel := range manifests {
+        pkg, file := filepath.Split(filepath.FromSlash(rel))
+        manifest, err := pm.ReadOutputManifest(filepath.Clean(pkg), strings.TrimSuffix(file, ".json"))
+        if err != nil {
+            return nil, err
+        }
+        if manifest == nil {
+            continue
+        }
+        for _, f := range manifest.Files {
+            owned[f.Path] = true
+        }
+    }
+    return owned, nil
+}
+
+// outputPath returns the location on disk of a path relative to OutputDir.
+func (pm *ProtoManager) outputPath(rel string) string {
+    return filepath.Join(pm.OutputDir, filepath.FromSlash(rel))
+}
+
+// removeOutputFile deletes a file relative to OutputDir along with any
+// directories the removal leaves empty.
+func (pm *ProtoManager) removeOutputFile(rel string) error {
+    path := pm.outputPath(rel)
+    if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+        return err
+    }
+    removeEmptyDirs(filepath.Dir(path), pm.OutputDir)
+    return nil
+}
+
+// removeEmptyDirs removes dir and its parents while they are empty, stopping at root.
+func removeEmptyDirs(dir, root string) {
+    root = filepath.Clean(root)
+    for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+        if err := os.Remove(dir); err != nil {
+            return
+        }
+    }
+}
@@ -0,0 +1,82 @@
+// protomanager/cleanup_test.go
+package protomanager
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "sort"
+    "testing"
+)
+
+// manifestOf returns a manifest listing paths, relative to pm.OutputDir, with
+// their current hashes.
+func manifestOf(t *testing.T, pm *ProtoManager, paths ...string) *OutputManifest {
+    t.Helper()
+    manifest := &OutputManifest{Package: "billing", Language: "go"}
+    for _, path := range paths {
+        digest, err := fileDigest(pm.outputPath(path))
+        if err != nil {
+            t.Fatal(err)
+        }
+        manifest.Files = append(manifest.Files, ManifestFile{Path: path, SHA256: digest})
+    }
+    return manifest
+}
+
+func TestCleanupStale(t *testing.T) {
+    const (
+        current   = "billing/go/billing.pb.go"
+        unchanged = "billing/go/old.pb.go"
+        edited    = "billing/go/edited.pb.go"
+        untracked = "billing/go/notes.txt"
+    )
+    tests := []struct {
+        mode    string
+        removed []string
+        kept    []string
+    }{
+        {mode: CleanupSafe, removed: []string{unchanged}, kept: []string{edited, untracked}},
+        {mode: CleanupDelete, removed: []string{edited, untracked, unchanged}},
+        {mode: CleanupReport, kept: []string{edited, untracked, unchanged}},
+        {mode: CleanupOff},
+    }
+    for _, tt := range tests {
+        t.Run(tt.mode, func(t *testing.T) {
+            pm := newTestProtoManager(t)
+            pm.Config.Cleanup.Mode = tt.mode
+            writeProtos(t, pm.OutputDir, map[string]string{current: "current", unchanged: "old", edited: "generated", untracked: "notes"})
+            previous := manifestOf(t, pm, current, unchanged, edited)
+            writeProtos(t, pm.OutputDir, map[string]string{edited: "edited by hand"})
+
+            target := OutputTarget{Package: "billing", Language: "go"}
+            stale, err := pm.CleanupStale(target, previous, manifestOf(t, pm, current), []string{filepath.Join(pm.OutputDir, "billing", "go")})
+            if err != nil {
+                t.Fatal(err)
+            }
+            var removed, kept []string
+            for _, file := range stale {
+                if file.Removed {
+                    removed = append(removed, file.Path)
+                } else {
+                    kept = append(kept, file.Path)
+                }
+            }
+            sort.Strings(removed)
+            sort.Strings(kept)
+            if !reflect.DeepEqual(removed, tt.removed) || !reflect.DeepEqual(kept, tt.kept) {
+                t.Errorf("removed %v and kept %v; want removed %v and kept %v", removed, kept, tt.removed, tt.kept)
+            }
+
+            gone := map[string]bool{}
+            for _, path := range tt.removed {
+                gone[path] = true
+            }
+            for _, path := range []string{current, unchanged, edited, untracked} {
+                if _, err := os.Stat(pm.outputPath(path)); os.IsNotExist(err) != gone[path] {
+                    t.Errorf("'%s' exists = %t after cleanup, want %t", path, err == nil, !gone[path])
+                }
+            }
+        })
+    }
+}
@@ -2,13 +2,15 @@
 package cluster
 
 import (
+    "context"
     "fmt"
     "sync"
 )
 
-// Task is the generic interface for tasks.
+// Task is the generic interface for tasks. Execute must stop, killing any
+// external command it started, once ctx is done.
 type Task[T any] interface {
-    Execute() (T, error)
+    Execute(ctx context.Context) (T, error)
 }
 
 // ClusterManager manages and runs tasks concurrently.
@@ -30,8 +32,8 @@ func (cm *ClusterManager[T]) AddTask(task Task[T]) {
 }
 
 // RunTasks executes all tasks concurrently and returns their results.
-// If any task fails, it returns an aggregated error.
-func (cm *ClusterManager[T]) RunTasks() ([]T, error) {
+// If any task fails, it returns an aggregated error. Every task receives ctx.
+func (cm *ClusterManager[T]) RunTasks(ctx context.Context) ([]T, error) {
     var wg sync.WaitGroup
     results := make([]T, len(cm.tasks))
     errors := make([]error, len(cm.tasks))
@@ -40,7 +42,7 @@ func (cm *ClusterManager[T]) RunTasks() ([]T, error) {
         wg.Add(1)
         go func(i int, task Task[T]) {
             defer wg.Done()
-            result, err := task.Execute()
+            result, err := task.Execute(ctx)
             results[i] = result
             errors[i] = err
         }(i, task)
@@ -49,16 +51,34 @@ func (cm *ClusterManager[T]) RunTasks() ([]T, error) {
     wg.Wait()
 
     // Aggregate errors
-    var aggErr string
+    var failed TaskErrors
     for _, err := range errors {
         if err != nil {
-            aggErr += err.Error() + "; "
+            failed = append(failed, err)
         }
     }
 
-    if aggErr != "" {
-        return results, fmt.Errorf("errors occurred: %s", aggErr)
+    if len(failed) > 0 {
+        return results, failed
     }
 
     return results, nil
+}
+
+// TaskErrors aggregates the errors of failed tasks. The individual errors stay
+// reachable through errors.Is and errors.As.
+type TaskErrors []error
+
+// Error implements the error interface.
+func (e TaskErrors) Error() string {
+    var aggErr string
+    for _, err := range e {
+        aggErr += err.Error() + "; "
+    }
+    return fmt.Sprintf("errors occurred: %s", aggErr)
+}
+
+// Unwrap returns the individual task errors.
+func (e TaskErrors) Unwrap() []error {
+    return e
 }
\ No newline at end of file
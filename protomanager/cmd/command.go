@@ -0,0 +1,553 @@
+// protomanager/cmd/command.go
+package cmd
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "flag"
+    "fmt"
+    "os"
+    "sort"
+    "strings"
+
+    "github.com/Cdaprod/protomanager"
+    "github.com/Cdaprod/protomanager/cluster"
+    "github.com/Cdaprod/protomanager/tasks"
+)
+
+// command is a single CLI subcommand.
+type command struct {
+    Usage string
+    Run   func(ctx context.Context, pm *protomanager.ProtoManager, args []string) error
+}
+
+// commands maps subcommand names to their implementations.
+var commands = map[string]command{
+    "register": {
+        Usage: "Register a microservice and update the global proto file",
+        Run:   runRegister,
+    },
+    "unregister": {
+        Usage: "Unregister a microservice and remove it from the global proto file",
+        Run:   runUnregister,
+    },
+    "reconcile": {
+        Usage: "Rebuild the global proto file from the registry and report drift",
+        Run:   runReconcile,
+    },
+    "generate": {
+        Usage: "Generate protobuf code for the global proto file or selected packages",
+        Run:   runGenerate,
+    },
+    "rollback": {
+        Usage: "Swap the previous generation back into the output directory",
+        Run:   runRollback,
+    },
+    "verify": {
+        Usage: "Check generated files against their output manifests",
+        Run:   runVerify,
+    },
+    "docs": {
+        Usage: "Write Markdown and HTML API reference docs for every registered service",
+        Run:   runDocs,
+    },
+    "descriptors": {
+        Usage: "Write FileDescriptorSets for the global proto file and every service",
+        Run:   runDescriptors,
+    },
+    "jsonschema": {
+        Usage: "Write JSON Schemas for every message of the registered services",
+        Run:   runJSONSchema,
+    },
+    "package": {
+        Usage: "Bundle generated code into versioned Go, Python and npm artifacts",
+        Run:   runPackage,
+    },
+    "lint": {
+        Usage: "Check proto files against naming, package, enum and comment rules",
+        Run:   runLint,
+    },
+    "fmt": {
+        Usage: "Format proto files canonically",
+        Run:   runFmt,
+    },
+    "vendor": {
+        Usage: "Vendor well-known and third-party protos into the local cache",
+        Run:   runVendor,
+    },
+    "toolchain": {
+        Usage: "Verify the pinned toolchain, installing it from the artifact cache",
+        Run:   runToolchain,
+    },
+    "templates": {
+        Usage: "List the available service definition templates",
+        Run:   runTemplates,
+    },
+}
+
+// Execute runs the subcommand named by args[0] against pm. Cancelling ctx
+// stops the subcommand and kills the external commands it started.
+// It is a no-op when args is empty.
+func Execute(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    if len(args) == 0 {
+        return nil
+    }
+
+    c, ok := commands[args[0]]
+    if !ok {
+        printUsage()
+        return fmt.Errorf("unknown command '%s'", args[0])
+    }
+    return c.Run(ctx, pm, args[1:])
+}
+
+// printUsage lists the available subcommands.
+func printUsage() {
+    names := make([]string, 0, len(commands))
+    for name := range commands {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    fmt.Fprintln(os.Stderr, "Commands:")
+    for _, name := range names {
+        fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].Usage)
+    }
+}
+
+// runRegister handles `protomanager register`.
+func runRegister(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("register", flag.ContinueOnError)
+    name := fs.String("name", "", "Microservice name")
+    domain := fs.String("domain", "", "Domain to register the microservice under")
+    version := fs.String("version", "v1", "Version of the microservice")
+    template := fs.String("template", "", "Template used to scaffold the service definition")
+    owners := fs.String("owners", "", "Comma-separated owners of the service, shown in the API docs")
+    force := fs.Bool("force", false, "Regenerate code even when the generation cache is up to date")
+    dryRun := fs.Bool("dry-run", false, "Print the changes without applying them")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    pm.Force = *force
+    if *name == "" || *domain == "" {
+        fs.Usage()
+        return fmt.Errorf("missing required arguments --name and --domain")
+    }
+
+    metadata := protomanager.ServiceMetadata{Domain: *domain, Version: *version, Template: *template, Owners: splitList(*owners)}
+    return runMaybeDry(pm, *dryRun, func(pm *protomanager.ProtoManager) error {
+        return pm.RegisterMicroserviceWithMetadata(ctx, *name, metadata)
+    })
+}
+
+// runUnregister handles `protomanager unregister`.
+func runUnregister(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("unregister", flag.ContinueOnError)
+    name := fs.String("name", "", "Microservice name")
+    force := fs.Bool("force", false, "Regenerate code even when the generation cache is up to date")
+    dryRun := fs.Bool("dry-run", false, "Print the changes without applying them")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    pm.Force = *force
+    if *name == "" {
+        fs.Usage()
+        return fmt.Errorf("missing required argument --name")
+    }
+
+    return runMaybeDry(pm, *dryRun, func(pm *protomanager.ProtoManager) error {
+        return pm.UnregisterMicroservice(ctx, *name)
+    })
+}
+
+// runReconcile handles `protomanager reconcile`.
+func runReconcile(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+    check := fs.Bool("check", false, "Report drift without rewriting the global proto file")
+    generate := fs.Bool("generate", false, "Regenerate code after reconciling")
+    force := fs.Bool("force", false, "Regenerate code even when the generation cache is up to date")
+    dryRun := fs.Bool("dry-run", false, "Print the changes without applying them")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    pm.Force = *force
+
+    var report *protomanager.ReconcileReport
+    err := runMaybeDry(pm, *dryRun, func(pm *protomanager.ProtoManager) error {
+        var err error
+        report, err = pm.Reconcile(ctx, protomanager.ReconcileOptions{Check: *check, Generate: *generate})
+        return err
+    })
+    if err != nil {
+        return err
+    }
+
+    fmt.Println(report)
+    if *check && report.HasDrift() {
+        return fmt.Errorf("global proto file has drifted from the registry")
+    }
+    return nil
+}
+
+// runGenerate handles `protomanager generate`.
+func runGenerate(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+    packages := fs.String("packages", "", "Comma-separated packages to generate; empty generates the global proto")
+    languages := fs.String("languages", "go", "Comma-separated target languages")
+    push := fs.Bool("push", false, "Push generated code to each package repository")
+    validate := fs.Bool("validate", false, "Validate package protos before generating")
+    commitMsg := fs.String("commit-msg", "Update generated protobufs", "Commit message used with --push")
+    descriptors := fs.Bool("descriptors", false, "Also write FileDescriptorSets")
+    jsonSchema := fs.Bool("jsonschema", false, "Also write JSON Schemas for every message")
+    force := fs.Bool("force", false, "Regenerate code even when the generation cache is up to date")
+    cleanup := fs.String("cleanup", "", "Stale file handling: safe, delete, report or off (default from config)")
+    dryRun := fs.Bool("dry-run", false, "Print the changes without applying them")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    pm.Force = *force
+    if *cleanup != "" {
+        pm.Config.Cleanup.Mode = *cleanup
+    }
+
+    pkgs := splitList(*packages)
+    if len(pkgs) == 0 {
+        return runMaybeDry(pm, *dryRun, func(pm *protomanager.ProtoManager) error {
+            return pm.StagedGeneration(ctx, func(pm *protomanager.ProtoManager) error {
+                if err := pm.GenerateProtoCode(ctx); err != nil {
+                    return err
+                }
+                if *descriptors {
+                    if _, err := pm.GenerateDescriptorSets(ctx); err != nil {
+                        return err
+                    }
+                }
+                if *jsonSchema {
+                    _, err := pm.GenerateJSONSchemas(ctx)
+                    return err
+                }
+                return nil
+            })
+        })
+    }
+
+    err := runMaybeDry(pm, *dryRun, func(pm *protomanager.ProtoManager) error {
+        if *validate {
+            validation := cluster.NewClusterManager[string]()
+            for _, pkg := range pkgs {
+                validation.AddTask(&tasks.ValidationTask{ProtoManager: pm, PackageName: pkg})
+            }
+            if _, err := validation.RunTasks(ctx); err != nil {
+                return err
+            }
+        }
+
+        return pm.StagedGeneration(ctx, func(pm *protomanager.ProtoManager) error {
+            generation := cluster.NewClusterManager[string]()
+            for _, pkg := range pkgs {
+                generation.AddTask(&tasks.ProtobufGenerationTask{ProtoManager: pm, PackageName: pkg, Languages: splitList(*languages)})
+                if *descriptors {
+                    generation.AddTask(&tasks.DescriptorSetTask{ProtoManager: pm, PackageName: pkg})
+                }
+            }
+            if _, err := generation.RunTasks(ctx); err != nil {
+                return err
+            }
+            if *jsonSchema {
+                _, err := pm.GenerateJSONSchemas(ctx, pkgs...)
+                return err
+            }
+            return nil
+        })
+    })
+    if err != nil || !*push {
+        return err
+    }
+
+    if *dryRun {
+        fmt.Println("Dry run: skipping push")
+        return nil
+    }
+    pushes := cluster.NewClusterManager[string]()
+    for _, pkg := range pkgs {
+        pushes.AddTask(&tasks.PushTask{ProtoManager: pm, PackageName: pkg, CommitMsg: *commitMsg})
+    }
+    _, err = pushes.RunTasks(ctx)
+    return err
+}
+
+// runRollback handles `protomanager rollback`.
+func runRollback(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    return pm.RollbackGeneration()
+}
+
+// runVerify handles `protomanager verify`.
+func runVerify(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    issues, err := pm.VerifyOutputs()
+    if err != nil {
+        return err
+    }
+    for _, issue := range issues {
+        fmt.Println(issue)
+    }
+    if len(issues) > 0 {
+        return fmt.Errorf("%d generated file(s) are missing or were modified; run `protomanager generate --force`", len(issues))
+    }
+    fmt.Println("all generated files match their manifests")
+    return nil
+}
+
+// runDescriptors handles `protomanager descriptors`.
+func runDescriptors(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("descriptors", flag.ContinueOnError)
+    dryRun := fs.Bool("dry-run", false, "Print the changes without applying them")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    return runMaybeDry(pm, *dryRun, func(pm *protomanager.ProtoManager) error {
+        return pm.StagedGeneration(ctx, func(pm *protomanager.ProtoManager) error {
+            paths, err := pm.GenerateDescriptorSets(ctx)
+            for _, path := range paths {
+                fmt.Println(path)
+            }
+            return err
+        })
+    })
+}
+
+// runDocs handles `protomanager docs`.
+func runDocs(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("docs", flag.ContinueOnError)
+    formats := fs.String("formats", "", "Comma-separated formats: markdown, html (default from config)")
+    dryRun := fs.Bool("dry-run", false, "Print the changes without applying them")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if list := splitList(*formats); len(list) > 0 {
+        pm.Config.Docs.Formats = list
+    }
+
+    return runMaybeDry(pm, *dryRun, func(pm *protomanager.ProtoManager) error {
+        return pm.StagedGeneration(ctx, func(pm *protomanager.ProtoManager) error {
+            paths, err := pm.GenerateDocs(ctx)
+            for _, path := range paths {
+                fmt.Println(path)
+            }
+            return err
+        })
+    })
+}
+
+// runJSONSchema handles `protomanager jsonschema`.
+func runJSONSchema(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("jsonschema", flag.ContinueOnError)
+    services := fs.String("services", "", "Comma-separated services; empty writes every service and the global proto")
+    dryRun := fs.Bool("dry-run", false, "Print the changes without applying them")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    return runMaybeDry(pm, *dryRun, func(pm *protomanager.ProtoManager) error {
+        return pm.StagedGeneration(ctx, func(pm *protomanager.ProtoManager) error {
+            paths, err := pm.GenerateJSONSchemas(ctx, splitList(*services)...)
+            for _, path := range paths {
+                fmt.Println(path)
+            }
+            return err
+        })
+    })
+}
+
+// runPackage handles `protomanager package`.
+func runPackage(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("package", flag.ContinueOnError)
+    services := fs.String("services", "", "Comma-separated services; empty packages every registered service")
+    languages := fs.String("languages", "", "Comma-separated languages; empty packages every generated one")
+    version := fs.String("version", "", "Version to stamp instead of the services' registered version")
+    dir := fs.String("dir", "", "Artifact directory (default from config)")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+    if *dir != "" {
+        pm.Config.Package.Dir = *dir
+    }
+
+    artifacts, err := pm.PackageArtifacts(ctx, protomanager.PackageOptions{
+        Services:  splitList(*services),
+        Languages: splitList(*languages),
+        Version:   *version,
+    })
+    for _, artifact := range artifacts {
+        fmt.Printf("%s  %s\n", artifact.SHA256, artifact.Path)
+    }
+    return err
+}
+
+// runLint handles `protomanager lint`.
+func runLint(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+    packages := fs.String("packages", "", "Comma-separated packages; empty lints the global proto and every service")
+    format := fs.String("format", "text", "Output format: text, github or json")
+    rules := fs.Bool("rules", false, "List the lint rules and their severities instead")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    if *rules {
+        for _, rule := range protomanager.LintRules() {
+            severity := rule.Severity
+            if override, ok := pm.Config.Lint.Rules[rule.ID]; ok {
+                severity = override
+            }
+            fmt.Printf("%-28s %-8s %s\n", rule.ID, severity, rule.Description)
+        }
+        return nil
+    }
+
+    names := splitList(*packages)
+    if len(names) == 0 {
+        services, err := pm.ProtoRegistry.ListServices()
+        if err != nil {
+            return err
+        }
+        names = []string{"global"}
+        for name := range services {
+            names = append(names, name)
+        }
+        sort.Strings(names[1:])
+    }
+
+    findings := []protomanager.LintFinding{}
+    for _, name := range names {
+        found, err := pm.LintPackage(ctx, name)
+        var none *protomanager.NoProtoFilesError
+        if errors.As(err, &none) && *packages == "" {
+            continue
+        }
+        if err != nil {
+            return fmt.Errorf("failed to lint '%s': %w", name, err)
+        }
+        findings = append(findings, found...)
+    }
+
+    switch *format {
+    case "text":
+        for _, f := range findings {
+            fmt.Println(f)
+        }
+    case "github":
+        for _, f := range findings {
+            fmt.Println(githubAnnotation(f.Diagnostic()))
+        }
+    case "json":
+        enc := json.NewEncoder(os.Stdout)
+        enc.SetIndent("", "  ")
+        if err := enc.Encode(findings); err != nil {
+            return err
+        }
+    default:
+        return fmt.Errorf("unknown lint format '%s'; use text, github or json", *format)
+    }
+
+    var errs int
+    for _, f := range findings {
+        if f.Severity == protomanager.LintSeverityError {
+            errs++
+        }
+    }
+    if errs > 0 {
+        return fmt.Errorf("lint found %d error(s)", errs)
+    }
+    return nil
+}
+
+// runFmt handles `protomanager fmt [--check] [paths...]`.
+func runFmt(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+    check := fs.Bool("check", false, "List unformatted files and fail instead of rewriting them")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    paths := fs.Args()
+    if len(paths) == 0 {
+        paths = []string{pm.GlobalProtoPath}
+    }
+
+    changed, err := protomanager.FormatPaths(paths, *check)
+    for _, path := range changed {
+        fmt.Println(path)
+    }
+    if err != nil {
+        return err
+    }
+    if *check && len(changed) > 0 {
+        return fmt.Errorf("%d file(s) are not formatted", len(changed))
+    }
+    return nil
+}
+
+// runVendor handles `protomanager vendor`.
+func runVendor(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    sources, err := pm.Vendor(ctx)
+    if err != nil {
+        return err
+    }
+    for _, source := range sources {
+        fmt.Printf("%s: %d file(s) from %s\n", source.Name, len(source.Files), source.Source)
+    }
+    return nil
+}
+
+// runToolchain handles `protomanager toolchain`.
+func runToolchain(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    tools, err := pm.EnsureToolchain(ctx)
+    if err != nil {
+        return err
+    }
+    if len(tools) == 0 {
+        fmt.Println("no tools are pinned; add a toolchain section to the config file")
+        return nil
+    }
+    for _, tool := range tools {
+        fmt.Printf("%-12s %-24s %s\n", tool.Name, tool.Version, tool.Path)
+    }
+    return nil
+}
+
+// runTemplates handles `protomanager templates`.
+func runTemplates(ctx context.Context, pm *protomanager.ProtoManager, args []string) error {
+    for _, name := range pm.TemplateNames() {
+        fmt.Println(name)
+    }
+    return nil
+}
+
+// runMaybeDry runs op against pm, or against a scratch copy of pm when dryRun
+// is set, printing a unified diff and file summary of what would change.
+func runMaybeDry(pm *protomanager.ProtoManager, dryRun bool, op func(pm *protomanager.ProtoManager) error) error {
+    if !dryRun {
+        return op(pm)
+    }
+
+    report, err := pm.DryRun(op)
+    if err != nil {
+        return err
+    }
+    fmt.Print(report.Diff)
+    fmt.Println(report.Summary())
+    return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+    var items []string
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
@@ -0,0 +1,73 @@
+// protomanager/cmd/diagnostics.go
+package cmd
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "strings"
+
+    "github.com/Cdaprod/protomanager"
+)
+
+// PrintDiagnostics writes the diagnostics carried by err to w in format:
+// "text" prints compiler-style "file:line:column: severity: message" lines
+// that editors can jump to, "github" prints GitHub Actions workflow commands
+// that annotate the offending lines, and "json" prints a JSON array.
+// Nothing is written when err carries no diagnostics.
+func PrintDiagnostics(w io.Writer, err error, format string) error {
+    diagnostics := protomanager.Diagnostics(err)
+    if len(diagnostics) == 0 {
+        return nil
+    }
+
+    switch format {
+    case "", "text":
+        for _, d := range diagnostics {
+            fmt.Fprintln(w, d)
+        }
+    case "github":
+        for _, d := range diagnostics {
+            fmt.Fprintln(w, githubAnnotation(d))
+        }
+    case "json":
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        if err := enc.Encode(diagnostics); err != nil {
+            return err
+        }
+    default:
+        return fmt.Errorf("unknown diagnostics format '%s'; use text, github or json", format)
+    }
+    return nil
+}
+
+// githubAnnotation renders d as a GitHub Actions ::error or ::warning command.
+func githubAnnotation(d protomanager.Diagnostic) string {
+    command := "error"
+    if d.Severity == "warning" {
+        command = "warning"
+    }
+
+    params := []string{"title=" + escapeAnnotation(d.Source, true)}
+    if d.File != "" {
+        params = append(params, "file="+escapeAnnotation(d.File, true))
+    }
+    if d.Line > 0 {
+        params = append(params, fmt.Sprintf("line=%d", d.Line))
+    }
+    if d.Column > 0 {
+        params = append(params, fmt.Sprintf("col=%d", d.Column))
+    }
+    return fmt.Sprintf("::%s %s::%s", command, strings.Join(params, ","), escapeAnnotation(d.Message, false))
+}
+
+// escapeAnnotation escapes a workflow command message or, when property is
+// set, a property value.
+func escapeAnnotation(s string, property bool) string {
+    s = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+    if property {
+        s = strings.NewReplacer(":", "%3A", ",", "%2C").Replace(s)
+    }
+    return s
+}
@@ -0,0 +1,44 @@
+// protomanager/cmd/diagnostics_test.go
+package cmd
+
+import (
+    "testing"
+
+    "github.com/Cdaprod/protomanager"
+)
+
+func TestGithubAnnotation(t *testing.T) {
+    tests := []struct {
+        name string
+        d    protomanager.Diagnostic
+        want string
+    }{
+        {
+            name: "located error",
+            d:    protomanager.Diagnostic{Source: "protoc", File: "billing/billing.proto", Line: 12, Column: 5, Severity: "error", Message: "\"Money\" is not defined."},
+            want: "::error title=protoc,file=billing/billing.proto,line=12,col=5::\"Money\" is not defined.",
+        },
+        {
+            name: "warning without position",
+            d:    protomanager.Diagnostic{Source: "lint", File: "a.proto", Severity: "warning", Message: "unused import"},
+            want: "::warning title=lint,file=a.proto::unused import",
+        },
+        {
+            name: "message escaping",
+            d:    protomanager.Diagnostic{Source: "go-vet", Severity: "error", Message: "100% broken\r\nsee: a, b"},
+            want: "::error title=go-vet::100%25 broken%0D%0Asee: a, b",
+        },
+        {
+            name: "property escaping",
+            d:    protomanager.Diagnostic{Source: "hook: a,b", File: "C:\\gen\\a,b.go", Line: 1, Severity: "error", Message: "x"},
+            want: "::error title=hook%3A a%2Cb,file=C%3A\\gen\\a%2Cb.go,line=1::x",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := githubAnnotation(tt.d); got != tt.want {
+                t.Errorf("githubAnnotation() =\n%s\nwant\n%s", got, tt.want)
+            }
+        })
+    }
+}
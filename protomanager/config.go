@@ -0,0 +1,223 @@
+// protomanager/config.go
+package protomanager
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3"
+)
+
+// Config holds the settings loaded from the configuration file.
+type Config struct {
+    GlobalProtoPath      string `yaml:"global_proto_path"`
+    MicroserviceProtoDir string `yaml:"microservice_proto_dir"`
+    OutputDir            string `yaml:"output_dir"`
+
+    // Templates maps a template name to a text/template file used to scaffold
+    // service definitions. Entries override built-in templates of the same name.
+    Templates map[string]string `yaml:"templates"`
+    // DefaultTemplate is used when a service is registered without a template.
+    DefaultTemplate string `yaml:"default_template"`
+
+    // Module is the base module path exposed to file option templates.
+    Module string `yaml:"module"`
+    // FileOptions maps a file option (go_package, java_package, ...) to a
+    // text/template rendered with FileOptionsData and injected into every
+    // managed proto file.
+    FileOptions map[string]string `yaml:"file_options"`
+    // GlobalFileOptions overrides FileOptions for the global proto file.
+    GlobalFileOptions map[string]string `yaml:"global_file_options"`
+
+    // Services holds per-service overrides keyed by service name.
+    Services map[string]ServiceConfig `yaml:"services"`
+
+    // IncludePaths are extra directories searched for imports during generation.
+    IncludePaths []string `yaml:"include_paths"`
+    // Vendor configures the local cache of well-known and third-party protos.
+    Vendor VendorConfig `yaml:"vendor"`
+
+    // Generator selects the code generation backend: "protoc" (default)
+    // executes protoc, "native" compiles protos in-process.
+    Generator string `yaml:"generator"`
+    // Languages maps a target language to its plugins, replacing the
+    // built-in mapping for that language.
+    Languages map[string]LanguageConfig `yaml:"languages"`
+    // Discovery selects the proto files compiled for each package.
+    Discovery DiscoveryConfig `yaml:"discovery"`
+    // Cleanup configures how generated files that are no longer produced are handled.
+    Cleanup CleanupConfig `yaml:"cleanup"`
+    // Toolchain pins and verifies protoc and plugin binaries.
+    Toolchain ToolchainConfig `yaml:"toolchain"`
+    // GoModule configures go.mod scaffolding for generated Go code.
+    GoModule GoModuleConfig `yaml:"go_module"`
+    // HTTP configures the HTTP mapping of the gateway and OpenAPI targets.
+    HTTP HTTPConfig `yaml:"http"`
+    // Timeouts bounds how long protoc, plugins, hooks and git may run.
+    Timeouts TimeoutConfig `yaml:"timeouts"`
+    // Fakes configures the Go fake servers written for every gRPC service.
+    Fakes FakesConfig `yaml:"fakes"`
+    // Docs configures the API reference written by `protomanager docs`.
+    Docs DocsConfig `yaml:"docs"`
+    // Package configures the versioned artifacts written by `protomanager package`.
+    Package PackageConfig `yaml:"package"`
+    // Lint overrides the severities of the lint rules.
+    Lint LintConfig `yaml:"lint"`
+}
+
+// ServiceConfig holds configuration overrides for a single service.
+type ServiceConfig struct {
+    // FileOptions overrides Config.FileOptions for the service's proto files.
+    FileOptions map[string]string `yaml:"file_options"`
+    // Languages overrides Config.Languages for the service.
+    Languages map[string]LanguageConfig `yaml:"languages"`
+    // Discovery overrides Config.Discovery patterns for the service.
+    Discovery DiscoveryConfig `yaml:"discovery"`
+}
+
+// DefaultConfig returns the configuration used when no file is loaded.
+func DefaultConfig() *Config {
+    return &Config{
+        GlobalProtoPath:      "./proto/global.proto",
+        MicroserviceProtoDir: "./proto/microservices",
+        OutputDir:            "./generated",
+        Templates:            map[string]string{},
+        DefaultTemplate:      defaultTemplateName,
+        Generator:            "protoc",
+        Cleanup:              CleanupConfig{Mode: CleanupSafe},
+        Vendor: VendorConfig{
+            CacheDir: "./.protomanager/vendor",
+        },
+        Toolchain: ToolchainConfig{
+            Dir: "./.protomanager/bin",
+        },
+        GoModule: GoModuleConfig{
+            GoVersion: "1.20",
+            Tidy:      true,
+            Require: map[string]string{
+                "github.com/grpc-ecosystem/grpc-gateway/v2": "v2.20.0",
+                "google.golang.org/grpc":                    "v1.64.0",
+                "google.golang.org/protobuf":                "v1.34.2",
+            },
+        },
+        HTTP: HTTPConfig{
+            DefaultRule: HTTPRuleConfig{
+                Method: "post",
+                Path:   "/{{.ProtoPackage}}.{{.ProtoService}}/{{.Method}}",
+                Body:   "*",
+            },
+        },
+        Timeouts: TimeoutConfig{
+            Protoc: 5 * time.Minute,
+            Plugin: 5 * time.Minute,
+            Hook:   10 * time.Minute,
+            Git:    5 * time.Minute,
+        },
+        Docs: DocsConfig{
+            Formats: []string{"markdown", "html"},
+        },
+        Package: PackageConfig{
+            Dir: "./artifacts",
+            Python: PythonPackageConfig{
+                Name:     "{{.Service}}-proto",
+                Requires: []string{"protobuf>=4.25", "grpcio>=1.64"},
+            },
+            NPM: NPMPackageConfig{
+                Name: "{{.Service}}-proto",
+                Dependencies: map[string]string{
+                    "@protobuf-ts/runtime":     "^2.9.4",
+                    "@protobuf-ts/runtime-rpc": "^2.9.4",
+                },
+            },
+        },
+    }
+}
+
+// LoadConfig reads the YAML configuration file at path on top of DefaultConfig.
+// Relative paths are resolved against the directory of the file.
+func LoadConfig(path string) (*Config, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read config file '%s': %w", path, err)
+    }
+
+    cfg := DefaultConfig()
+    if err := yaml.Unmarshal(data, cfg); err != nil {
+        return nil, fmt.Errorf("failed to parse config file '%s': %w", path, err)
+    }
+
+    base := filepath.Dir(path)
+    for name, file := range cfg.Templates {
+        cfg.Templates[name] = resolvePath(base, file)
+    }
+    for i, dir := range cfg.IncludePaths {
+        cfg.IncludePaths[i] = resolvePath(base, dir)
+    }
+    resolveLanguages(base, cfg.Languages)
+    for _, svc := range cfg.Services {
+        resolveLanguages(base, svc.Languages)
+    }
+    cfg.Toolchain.Dir = resolvePath(base, cfg.Toolchain.Dir)
+    cfg.Toolchain.ArtifactCache = resolvePath(base, cfg.Toolchain.ArtifactCache)
+    cfg.Vendor.CacheDir = resolvePath(base, cfg.Vendor.CacheDir)
+    cfg.Vendor.WellKnownTypesDir = resolvePath(base, cfg.Vendor.WellKnownTypesDir)
+    cfg.Package.Dir = resolvePath(base, cfg.Package.Dir)
+    for i, tp := range cfg.Vendor.ThirdParty {
+        if !isRepositoryURL(tp.Source) {
+            cfg.Vendor.ThirdParty[i].Source = resolvePath(base, tp.Source)
+        }
+    }
+    if err := cfg.validatePatterns(); err != nil {
+        return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+    }
+    return cfg, nil
+}
+
+// validatePatterns rejects malformed discovery and vendor file patterns,
+// which would otherwise never match anything.
+func (cfg *Config) validatePatterns() error {
+    if err := validateGlobs("discovery.include", cfg.Discovery.Include); err != nil {
+        return err
+    }
+    if err := validateGlobs("discovery.exclude", cfg.Discovery.Exclude); err != nil {
+        return err
+    }
+    for _, name := range sortedKeys(cfg.Services) {
+        discovery := cfg.Services[name].Discovery
+        if err := validateGlobs(fmt.Sprintf("services.%s.discovery.include", name), discovery.Include); err != nil {
+            return err
+        }
+        if err := validateGlobs(fmt.Sprintf("services.%s.discovery.exclude", name), discovery.Exclude); err != nil {
+            return err
+        }
+    }
+    for _, tp := range cfg.Vendor.ThirdParty {
+        if err := validateGlobs(fmt.Sprintf("vendor.third_party.%s.files", tp.Name), tp.Files); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// resolvePath returns path joined to base unless it is already absolute.
+func resolvePath(base, path string) string {
+    if path == "" || filepath.IsAbs(path) {
+        return path
+    }
+    return filepath.Join(base, path)
+}
+
+// resolveLanguages resolves plugin binaries given as relative paths against base.
+// Bare executable names are left to be looked up on PATH.
+func resolveLanguages(base string, languages map[string]LanguageConfig) {
+    for _, language := range languages {
+        for i, plugin := range language.Plugins {
+            if strings.ContainsRune(plugin.Binary, filepath.Separator) {
+                language.Plugins[i].Binary = resolvePath(base, plugin.Binary)
+            }
+        }
+    }
+}
@@ -0,0 +1,134 @@
+// protomanager/declarations.go
+package protomanager
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+
+    "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// globalOwner owns the declarations of the global proto file that are
+// outside every service block.
+const globalOwner = "global"
+
+// serviceDeclaration is a top-level service, message or enum and the
+// registered service it belongs to, or globalOwner.
+type serviceDeclaration struct {
+    Service string
+    Desc    protoreflect.Descriptor
+}
+
+// serviceDeclarations compiles the global proto file and the proto files of
+// services, returning their top-level declarations: the global proto file's
+// first, then each service's in name order. Within a file they are grouped by
+// kind (services, messages, enums), each group in declaration order.
+// Declarations in the global proto file belong to the service whose block
+// contains them; declarations in a service's own files belong to it.
+func (pm *ProtoManager) serviceDeclarations(ctx context.Context, services map[string]ServiceMetadata) ([]serviceDeclaration, error) {
+    content, err := os.ReadFile(pm.GlobalProtoPath)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read global proto file: %w", err)
+    }
+    blocks := serviceBlockLines(string(content))
+    _, compiled, err := compileProtos(ctx, pm.IncludePaths(), []string{pm.GlobalProtoPath})
+    if err != nil {
+        return nil, err
+    }
+
+    var declarations []serviceDeclaration
+    for _, desc := range topLevelDeclarations(compiled[0]) {
+        owner := globalOwner
+        line := compiled[0].SourceLocations().ByDescriptor(desc).StartLine
+        for name, span := range blocks {
+            if _, ok := services[name]; ok && line > span[0] && line < span[1] {
+                owner = name
+                break
+            }
+        }
+        declarations = append(declarations, serviceDeclaration{owner, desc})
+    }
+
+    for _, name := range sortedKeys(services) {
+        files, err := pm.DiscoverProtos(name)
+        var none *NoProtoFilesError
+        if errors.As(err, &none) {
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+        _, compiled, err := compileProtos(ctx, pm.IncludePaths(pm.ProtoPath(name)), files)
+        if err != nil {
+            return nil, err
+        }
+        for _, file := range compiled {
+            for _, desc := range topLevelDeclarations(file) {
+                declarations = append(declarations, serviceDeclaration{name, desc})
+            }
+        }
+    }
+    return declarations, nil
+}
+
+// serviceBlockLines returns the zero-based lines of the begin and end
+// markers of every service block in the global proto content.
+func serviceBlockLines(content string) map[string][2]int {
+    blocks := map[string][2]int{}
+    for i, line := range strings.Split(content, "\n") {
+        trimmed := strings.TrimSpace(line)
+        if strings.HasPrefix(trimmed, serviceBeginMarker) {
+            blocks[strings.TrimSpace(strings.TrimPrefix(trimmed, serviceBeginMarker))] = [2]int{i, -1}
+        } else if strings.HasPrefix(trimmed, serviceEndMarker) {
+            name := strings.TrimSpace(strings.TrimPrefix(trimmed, serviceEndMarker))
+            if span, ok := blocks[name]; ok {
+                blocks[name] = [2]int{span[0], i}
+            }
+        }
+    }
+    return blocks
+}
+
+// topLevelDeclarations returns the services, messages and enums declared at
+// the top level of file, grouped by kind in that order.
+func topLevelDeclarations(file protoreflect.FileDescriptor) []protoreflect.Descriptor {
+    var decls []protoreflect.Descriptor
+    for i := 0; i < file.Services().Len(); i++ {
+        decls = append(decls, file.Services().Get(i))
+    }
+    for i := 0; i < file.Messages().Len(); i++ {
+        decls = append(decls, file.Messages().Get(i))
+    }
+    for i := 0; i < file.Enums().Len(); i++ {
+        decls = append(decls, file.Enums().Get(i))
+    }
+    return decls
+}
+
+// declaredTypes returns the full names of desc and the messages and enums
+// nested in it, skipping synthetic map entries.
+func declaredTypes(desc protoreflect.Descriptor) []protoreflect.FullName {
+    var names []protoreflect.FullName
+    walkTypes(desc, func(d protoreflect.Descriptor) { names = append(names, d.FullName()) })
+    return names
+}
+
+// walkTypes calls fn for desc and every message and enum nested in it.
+func walkTypes(desc protoreflect.Descriptor, fn func(protoreflect.Descriptor)) {
+    fn(desc)
+    message, ok := desc.(protoreflect.MessageDescriptor)
+    if !ok {
+        return
+    }
+    for i := 0; i < message.Messages().Len(); i++ {
+        if nested := message.Messages().Get(i); !nested.IsMapEntry() {
+            walkTypes(nested, fn)
+        }
+    }
+    for i := 0; i < message.Enums().Len(); i++ {
+        fn(message.Enums().Get(i))
+    }
+}
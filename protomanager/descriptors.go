@@ -0,0 +1,111 @@
+// protomanager/descriptors.go
+package protomanager
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+)
+
+const (
+    // descriptorsDir is the subdirectory of OutputDir holding descriptor sets.
+    descriptorsDir = "descriptors"
+    // descriptorSetExt is the extension used for binary FileDescriptorSet files.
+    descriptorSetExt = ".binpb"
+    // unversioned is used in place of an empty ServiceMetadata.Version.
+    unversioned = "unversioned"
+)
+
+// GlobalDescriptorSetPath returns where the descriptor set of the global proto file is written.
+func (pm *ProtoManager) GlobalDescriptorSetPath() string {
+    return filepath.Join(pm.OutputDir, descriptorsDir, "global"+descriptorSetExt)
+}
+
+// DescriptorSetPath returns where the descriptor set of a service version is written.
+func (pm *ProtoManager) DescriptorSetPath(serviceName, version string) string {
+    if version == "" {
+        version = unversioned
+    }
+    return filepath.Join(pm.OutputDir, descriptorsDir, serviceName, version, serviceName+descriptorSetExt)
+}
+
+// GenerateDescriptorSets writes a FileDescriptorSet, including imports and
+// source info, for the global proto file and for every registered service.
+// It returns the paths written.
+func (pm *ProtoManager) GenerateDescriptorSets(ctx context.Context) ([]string, error) {
+    services, err := pm.ProtoRegistry.ListServices()
+    if err != nil {
+        pm.Logger.Errorf("Failed to list registered services: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to list registered services: %v", err)})
+        return nil, err
+    }
+
+    global := pm.GlobalDescriptorSetPath()
+    if err := pm.writeDescriptorSet(ctx, global, pm.IncludePaths(), []string{pm.GlobalProtoPath}); err != nil {
+        return nil, err
+    }
+    written := []string{global}
+
+    for _, name := range sortedKeys(services) {
+        path, err := pm.GenerateServiceDescriptorSet(ctx, name, services[name])
+        if err != nil {
+            return written, err
+        }
+        if path != "" {
+            written = append(written, path)
+        }
+    }
+    return written, nil
+}
+
+// GenerateServiceDescriptorSet writes the descriptor set for one service's
+// proto files, versioned by metadata.Version. It returns "" when the service
+// has no proto files.
+func (pm *ProtoManager) GenerateServiceDescriptorSet(ctx context.Context, serviceName string, metadata ServiceMetadata) (string, error) {
+    protoPath := pm.ProtoPath(serviceName)
+    files, err := pm.DiscoverProtos(serviceName)
+    var none *NoProtoFilesError
+    if errors.As(err, &none) {
+        pm.Logger.Warnf("Skipping descriptor set: %v", err)
+        return "", nil
+    }
+    if err != nil {
+        return "", err
+    }
+
+    out := pm.DescriptorSetPath(serviceName, metadata.Version)
+    if err := pm.writeDescriptorSet(ctx, out, pm.IncludePaths(protoPath), files); err != nil {
+        return "", err
+    }
+    return out, nil
+}
+
+// writeDescriptorSet uses the configured Generator to write the descriptor set of files to out.
+func (pm *ProtoManager) writeDescriptorSet(ctx context.Context, out string, includePaths, files []string) error {
+    if _, err := pm.ResolveImports(includePaths, files...); err != nil {
+        return err
+    }
+    if err := os.MkdirAll(filepath.Dir(out), os.ModePerm); err != nil {
+        pm.Logger.Errorf("Failed to create output directory '%s': %v", filepath.Dir(out), err)
+        return err
+    }
+
+    err := pm.Generate(ctx, GenerateRequest{
+        IncludePaths:      includePaths,
+        Files:             files,
+        DescriptorSetOut:  out,
+        IncludeImports:    true,
+        IncludeSourceInfo: true,
+    })
+    if err != nil {
+        pm.Logger.Errorf("Failed to write descriptor set '%s': %v", out, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to write descriptor set '%s': %v", out, err)})
+        return err
+    }
+
+    pm.Logger.Infof("Descriptor set written to '%s'", out)
+    pm.emitEvent(Event{Type: "DescriptorSetGenerated", Message: fmt.Sprintf("Descriptor set written to '%s'", out)})
+    return nil
+}
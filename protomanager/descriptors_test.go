@@ -0,0 +1,57 @@
+// protomanager/descriptors_test.go
+package protomanager
+
+import (
+    "context"
+    "reflect"
+    "testing"
+)
+
+// TestGenerateServiceDescriptorSet checks that a service's descriptor set
+// holds its files, with source info, together with their imports.
+func TestGenerateServiceDescriptorSet(t *testing.T) {
+    pm := newTestProtoManager(t)
+    pm.Config.Generator = "native"
+    writeProtos(t, pm.ProtoPath("billing"), map[string]string{
+        "billing/billing.proto": "syntax = \"proto3\";\npackage billing;\nimport \"billing/money.proto\";\n// Invoice is billed to a customer.\nmessage Invoice { Money total = 1; }\n",
+        "billing/money.proto":   "syntax = \"proto3\";\npackage billing;\nimport \"google/protobuf/timestamp.proto\";\nmessage Money { int64 units = 1; google.protobuf.Timestamp at = 2; }\n",
+    })
+
+    path, err := pm.GenerateServiceDescriptorSet(context.Background(), "billing", ServiceMetadata{Version: "v1"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := pm.DescriptorSetPath("billing", "v1"); path != want {
+        t.Errorf("GenerateServiceDescriptorSet() = %s, want %s", path, want)
+    }
+
+    set := readDescriptorSet(t, path)
+    var names []string
+    for _, fd := range set.File {
+        names = append(names, fd.GetName())
+        // The well-known types bundled with the native compiler carry no source info.
+        if fd.GetPackage() == "billing" && fd.SourceCodeInfo == nil {
+            t.Errorf("'%s' has no source info", fd.GetName())
+        }
+    }
+    want := []string{"google/protobuf/timestamp.proto", "billing/money.proto", "billing/billing.proto"}
+    if !reflect.DeepEqual(names, want) {
+        t.Errorf("descriptor set files = %v, want %v", names, want)
+    }
+
+    // Comments survive in the source info.
+    var comment string
+    for _, fd := range set.File {
+        if fd.GetName() != "billing/billing.proto" {
+            continue
+        }
+        for _, location := range fd.GetSourceCodeInfo().GetLocation() {
+            if reflect.DeepEqual(location.GetPath(), []int32{4, 0}) {
+                comment = location.GetLeadingComments()
+            }
+        }
+    }
+    if comment != " Invoice is billed to a customer.\n" {
+        t.Errorf("Invoice leading comment = %q", comment)
+    }
+}
@@ -0,0 +1,164 @@
+// protomanager/diagnostics.go
+package protomanager
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+var (
+    // diagnosticPattern matches "file:line[:column]: message" lines as printed
+    // by the Go toolchain, protoc and most linters.
+    diagnosticPattern = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?:\s*(.*)$`)
+    // fileDiagnosticPattern matches protoc's "file.proto: message" lines,
+    // which name a file but no position.
+    fileDiagnosticPattern = regexp.MustCompile(`^(\S+\.proto):\s+(.*)$`)
+    // pluginDiagnosticPattern matches protoc's "--name_out: message" lines
+    // reporting a plugin failure.
+    pluginDiagnosticPattern = regexp.MustCompile(`^--([\w-]+)_out:\s*(.*)$`)
+)
+
+// Diagnostic is a single problem reported by a tool, located in a file when
+// the tool's output allows it.
+type Diagnostic struct {
+    Source   string `json:"source"` // Tool or hook that reported it
+    File     string `json:"file,omitempty"`
+    Line     int    `json:"line,omitempty"`
+    Column   int    `json:"column,omitempty"`
+    Severity string `json:"severity"`
+    Message  string `json:"message"`
+}
+
+// String implements fmt.Stringer.
+func (d Diagnostic) String() string {
+    var location string
+    switch {
+    case d.File != "" && d.Column > 0:
+        location = fmt.Sprintf("%s:%d:%d: ", d.File, d.Line, d.Column)
+    case d.File != "" && d.Line > 0:
+        location = fmt.Sprintf("%s:%d: ", d.File, d.Line)
+    case d.File != "":
+        location = d.File + ": "
+    }
+    return fmt.Sprintf("%s%s: %s (%s)", location, d.Severity, d.Message, d.Source)
+}
+
+// GenerateError reports a failed compiler or plugin run with the diagnostics
+// parsed from its output.
+type GenerateError struct {
+    Tool        string // protoc, or the plugin that failed
+    Diagnostics []Diagnostic
+    Err         error // Underlying failure, e.g. the exit status or a timeout
+}
+
+// Error implements the error interface.
+func (e *GenerateError) Error() string {
+    lines := make([]string, 0, len(e.Diagnostics))
+    for _, d := range e.Diagnostics {
+        lines = append(lines, "  "+d.String())
+    }
+    if len(lines) == 0 {
+        return fmt.Sprintf("%s failed: %v", e.Tool, e.Err)
+    }
+    return fmt.Sprintf("%s failed: %v\n%s", e.Tool, e.Err, strings.Join(lines, "\n"))
+}
+
+// Unwrap returns the underlying failure.
+func (e *GenerateError) Unwrap() error {
+    return e.Err
+}
+
+// Diagnostics collects the diagnostics carried by err and every error it
+// wraps, such as *GenerateError, *HookError and *LintError.
+func Diagnostics(err error) []Diagnostic {
+    var diagnostics []Diagnostic
+    switch e := err.(type) {
+    case nil:
+        return nil
+    case *GenerateError:
+        diagnostics = append(diagnostics, e.Diagnostics...)
+    case *HookError:
+        diagnostics = append(diagnostics, e.Diagnostics...)
+    case *LintError:
+        for _, f := range e.Findings {
+            diagnostics = append(diagnostics, f.Diagnostic())
+        }
+    }
+
+    switch e := err.(type) {
+    case interface{ Unwrap() []error }:
+        for _, wrapped := range e.Unwrap() {
+            diagnostics = append(diagnostics, Diagnostics(wrapped)...)
+        }
+    default:
+        diagnostics = append(diagnostics, Diagnostics(errors.Unwrap(err))...)
+    }
+    return diagnostics
+}
+
+// newGenerateError builds a *GenerateError for a run of tool that failed
+// with err and printed output, locating files against includePaths.
+func newGenerateError(tool string, err error, output string, includePaths []string) *GenerateError {
+    diagnostics := locateDiagnostics(parseDiagnostics(tool, output), includePaths)
+    return &GenerateError{Tool: tool, Diagnostics: diagnostics, Err: err}
+}
+
+// parseDiagnostics turns tool output into diagnostics. Lines of the form
+// "file:line[:column]: message", optionally prefixed with "vet: " as go vet
+// reports type errors, are located; other non-empty lines, except Go's
+// "# package" headers, become unlocated diagnostics.
+func parseDiagnostics(source, output string) []Diagnostic {
+    var diagnostics []Diagnostic
+    for _, line := range strings.Split(output, "\n") {
+        line = strings.TrimPrefix(strings.TrimSpace(line), "vet: ")
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+
+        d := Diagnostic{Source: source, Severity: "error", Message: line}
+        if m := diagnosticPattern.FindStringSubmatch(line); m != nil {
+            d.File = m[1]
+            d.Line, _ = strconv.Atoi(m[2])
+            d.Column, _ = strconv.Atoi(m[3])
+            d.Message = m[4]
+        } else if m := pluginDiagnosticPattern.FindStringSubmatch(line); m != nil {
+            d.Source = "protoc-gen-" + m[1]
+            d.Message = m[2]
+        } else if m := fileDiagnosticPattern.FindStringSubmatch(line); m != nil {
+            d.File = m[1]
+            d.Message = m[2]
+        }
+        for _, severity := range []string{"warning", "error"} {
+            if prefix := severity + ":"; strings.HasPrefix(strings.ToLower(d.Message), prefix) {
+                d.Severity = severity
+                d.Message = strings.TrimSpace(d.Message[len(prefix):])
+            }
+        }
+        diagnostics = append(diagnostics, d)
+    }
+    return diagnostics
+}
+
+// locateDiagnostics rewrites the import-relative file names reported by
+// protoc and plugins into paths on disk, using the first of dirs that
+// contains the file. Names that cannot be found are left as reported.
+func locateDiagnostics(diagnostics []Diagnostic, dirs []string) []Diagnostic {
+    for i, d := range diagnostics {
+        if d.File == "" || filepath.IsAbs(d.File) {
+            continue
+        }
+        for _, dir := range dirs {
+            path := filepath.Join(dir, filepath.FromSlash(d.File))
+            if _, err := os.Stat(path); err == nil {
+                diagnostics[i].File = path
+                break
+            }
+        }
+    }
+    return diagnostics
+}
@@ -0,0 +1,97 @@
+// protomanager/diagnostics_test.go
+package protomanager
+
+import (
+    "errors"
+    "fmt"
+    "reflect"
+    "testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+    tests := []struct {
+        name   string
+        source string
+        output string
+        want   []Diagnostic
+    }{
+        {
+            name:   "protoc error with column",
+            source: "protoc",
+            output: "billing/billing.proto:12:5: \"Money\" is not defined.\n",
+            want:   []Diagnostic{{Source: "protoc", File: "billing/billing.proto", Line: 12, Column: 5, Severity: "error", Message: "\"Money\" is not defined."}},
+        },
+        {
+            name:   "protoc warning",
+            source: "protoc",
+            output: "billing/billing.proto:4:1: warning: Import google/protobuf/empty.proto is unused.\n",
+            want:   []Diagnostic{{Source: "protoc", File: "billing/billing.proto", Line: 4, Column: 1, Severity: "warning", Message: "Import google/protobuf/empty.proto is unused."}},
+        },
+        {
+            name:   "protoc file without position",
+            source: "protoc",
+            output: "billing/billing.proto: File not found.\n",
+            want:   []Diagnostic{{Source: "protoc", File: "billing/billing.proto", Severity: "error", Message: "File not found."}},
+        },
+        {
+            name:   "plugin failure",
+            source: "protoc",
+            output: "--go_out: protoc-gen-go: Plugin failed with status code 1.\n",
+            want:   []Diagnostic{{Source: "protoc-gen-go", Severity: "error", Message: "protoc-gen-go: Plugin failed with status code 1."}},
+        },
+        {
+            name:   "go vet",
+            source: "go-vet",
+            output: "# example.com/billing\n./billing.pb.go:5:24: fmt.Printf format %d has arg \"s\" of wrong type string\nvet: ./fake.go:3:12: undefined: y\n",
+            want: []Diagnostic{
+                {Source: "go-vet", File: "./billing.pb.go", Line: 5, Column: 24, Severity: "error", Message: "fmt.Printf format %d has arg \"s\" of wrong type string"},
+                {Source: "go-vet", File: "./fake.go", Line: 3, Column: 12, Severity: "error", Message: "undefined: y"},
+            },
+        },
+        {
+            name:   "no column",
+            source: "lint",
+            output: "billing.proto:7: Error: field name should be lower_snake_case\n",
+            want:   []Diagnostic{{Source: "lint", File: "billing.proto", Line: 7, Severity: "error", Message: "field name should be lower_snake_case"}},
+        },
+        {
+            name:   "unparseable line",
+            source: "protoc",
+            output: "\nprotoc-gen-ts: program not found or is not executable\n\n",
+            want:   []Diagnostic{{Source: "protoc", Severity: "error", Message: "protoc-gen-ts: program not found or is not executable"}},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := parseDiagnostics(tt.source, tt.output)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("parseDiagnostics() =\n%+v\nwant\n%+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDiagnosticsUnwraps(t *testing.T) {
+    generate := &GenerateError{Tool: "protoc", Diagnostics: []Diagnostic{{Source: "protoc", File: "a.proto", Line: 1, Severity: "error", Message: "a"}}}
+    hook := &HookError{Diagnostics: []Diagnostic{{Source: "go-vet", File: "a.go", Line: 2, Severity: "error", Message: "b"}}}
+
+    tests := []struct {
+        name string
+        err  error
+        want int
+    }{
+        {"nil", nil, 0},
+        {"plain", errors.New("failed"), 0},
+        {"direct", generate, 1},
+        {"wrapped", fmt.Errorf("billing/go: %w", generate), 1},
+        {"wrapped twice", fmt.Errorf("generate: %w", fmt.Errorf("billing/go: %w", hook)), 1},
+        {"joined", errors.Join(fmt.Errorf("billing/go: %w", generate), errors.New("other"), hook), 2},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := Diagnostics(tt.err); len(got) != tt.want {
+                t.Errorf("Diagnostics() = %v, want %d diagnostic(s)", got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,213 @@
+// protomanager/diff.go
+package protomanager
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// diffOp is a single line in an edit script: ' ' (equal), '-' (delete) or '+' (insert).
+type diffOp struct {
+    Kind byte
+    Line string
+}
+
+// unifiedDiff returns a unified diff turning from into to, or "" when they are equal.
+func unifiedDiff(fromName, toName, from, to string) string {
+    if from == to {
+        return ""
+    }
+
+    ops := diffLines(splitLines(from), splitLines(to))
+
+    // aPos[i] and bPos[i] are the number of from/to lines consumed before ops[i].
+    aPos := make([]int, len(ops)+1)
+    bPos := make([]int, len(ops)+1)
+    for i, op := range ops {
+        aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+        if op.Kind != '+' {
+            aPos[i+1]++
+        }
+        if op.Kind != '-' {
+            bPos[i+1]++
+        }
+    }
+
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+    for i := 0; i < len(ops); {
+        if ops[i].Kind == ' ' {
+            i++
+            continue
+        }
+
+        // Extend the hunk while the next change is close enough to share context.
+        end := i
+        for j := i; j < len(ops) && j-end <= 2*diffContext; j++ {
+            if ops[j].Kind != ' ' {
+                end = j
+            }
+        }
+        start := i - diffContext
+        if start < 0 {
+            start = 0
+        }
+        stop := end + diffContext + 1
+        if stop > len(ops) {
+            stop = len(ops)
+        }
+
+        fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+            hunkRange(aPos[start], aPos[stop]-aPos[start]),
+            hunkRange(bPos[start], bPos[stop]-bPos[start]))
+        for _, op := range ops[start:stop] {
+            sb.WriteByte(op.Kind)
+            sb.WriteString(op.Line)
+            if !strings.HasSuffix(op.Line, "\n") {
+                sb.WriteString("\n\\ No newline at end of file\n")
+            }
+        }
+        i = stop
+    }
+    return sb.String()
+}
+
+// hunkRange formats the start,length pair of a hunk header.
+func hunkRange(start, length int) string {
+    if length == 0 {
+        return fmt.Sprintf("%d,0", start)
+    }
+    return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// splitLines splits s into lines, keeping their line terminators.
+func splitLines(s string) []string {
+    lines := strings.SplitAfter(s, "\n")
+    if lines[len(lines)-1] == "" {
+        lines = lines[:len(lines)-1]
+    }
+    return lines
+}
+
+// diffLines computes a shortest edit script from a to b with the linear
+// space variant of Myers' algorithm, which recursively splits both inputs
+// at the middle snake of an optimal path.
+func diffLines(a, b []string) []diffOp {
+    ops := appendDiff(make([]diffOp, 0, len(a)+len(b)), a, b)
+
+    // List deletions before insertions within each run of changes.
+    for i := 0; i < len(ops); {
+        if ops[i].Kind == ' ' {
+            i++
+            continue
+        }
+        j := i
+        for j < len(ops) && ops[j].Kind != ' ' {
+            j++
+        }
+        run := ops[i:j]
+        sort.SliceStable(run, func(x, y int) bool { return run[x].Kind == '-' && run[y].Kind == '+' })
+        i = j
+    }
+    return ops
+}
+
+// appendDiff appends the edit script from a to b to ops.
+func appendDiff(ops []diffOp, a, b []string) []diffOp {
+    prefix := 0
+    for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+        ops = append(ops, diffOp{Kind: ' ', Line: a[prefix]})
+        prefix++
+    }
+    a, b = a[prefix:], b[prefix:]
+    suffix := 0
+    for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+        suffix++
+    }
+    common := a[len(a)-suffix:]
+    a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+    switch {
+    case len(a) == 0:
+        for _, line := range b {
+            ops = append(ops, diffOp{Kind: '+', Line: line})
+        }
+    case len(b) == 0:
+        for _, line := range a {
+            ops = append(ops, diffOp{Kind: '-', Line: line})
+        }
+    default:
+        // Both sides differ at their first and last lines, so the script
+        // has at least two edits and each half is strictly smaller.
+        x, y, u, v := middleSnake(a, b)
+        ops = appendDiff(ops, a[:x], b[:y])
+        for _, line := range a[x:u] {
+            ops = append(ops, diffOp{Kind: ' ', Line: line})
+        }
+        ops = appendDiff(ops, a[u:], b[v:])
+    }
+
+    for _, line := range common {
+        ops = append(ops, diffOp{Kind: ' ', Line: line})
+    }
+    return ops
+}
+
+// middleSnake returns the start (x, y) and end (u, v) of the snake in the
+// middle of a shortest edit path from a to b, searching forward from the
+// start and backward from the end at the same time. The backward search
+// runs on the reversed inputs, so diagonal k there is delta-k here.
+func middleSnake(a, b []string) (x, y, u, v int) {
+    n, m := len(a), len(b)
+    delta := n - m
+    odd := delta%2 != 0
+    max := (n + m + 1) / 2
+    offset := max + 1
+    forward := make([]int, 2*max+3)
+    backward := make([]int, 2*max+3)
+
+    for d := 0; d <= max; d++ {
+        for k := -d; k <= d; k += 2 {
+            var x int
+            if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+                x = forward[offset+k+1]
+            } else {
+                x = forward[offset+k-1] + 1
+            }
+            y := x - k
+            startX, startY := x, y
+            for x < n && y < m && a[x] == b[y] {
+                x++
+                y++
+            }
+            forward[offset+k] = x
+            if rk := delta - k; odd && rk >= -(d-1) && rk <= d-1 && x+backward[offset+rk] >= n {
+                return startX, startY, x, y
+            }
+        }
+        for k := -d; k <= d; k += 2 {
+            var x int
+            if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+                x = backward[offset+k+1]
+            } else {
+                x = backward[offset+k-1] + 1
+            }
+            y := x - k
+            startX, startY := x, y
+            for x < n && y < m && a[n-1-x] == b[m-1-y] {
+                x++
+                y++
+            }
+            backward[offset+k] = x
+            if fk := delta - k; !odd && fk >= -d && fk <= d && x+forward[offset+fk] >= n {
+                return n - x, m - y, n - startX, m - startY
+            }
+        }
+    }
+    panic("diff: no middle snake") // A path of at most n+m edits always exists
+}
@@ -0,0 +1,144 @@
+// protomanager/diff_test.go
+package protomanager
+
+import (
+    "fmt"
+    "math/rand"
+    "runtime"
+    "strings"
+    "testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+    tests := []struct {
+        name     string
+        from, to string
+        want     string
+    }{
+        {
+            name: "equal",
+            from: "a\nb\n",
+            to:   "a\nb\n",
+            want: "",
+        },
+        {
+            name: "new file",
+            from: "",
+            to:   "a\nb\n",
+            want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+        },
+        {
+            name: "deleted file",
+            from: "a\nb\n",
+            to:   "",
+            want: "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+        },
+        {
+            name: "change with context",
+            from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+            to:   "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+            want: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+        },
+        {
+            name: "separate hunks",
+            from: "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+            to:   "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+            want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+        },
+        {
+            name: "missing final newline",
+            from: "a\nb",
+            to:   "a\nb\n",
+            want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := unifiedDiff("a", "b", tt.from, tt.to); got != tt.want {
+                t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+            }
+        })
+    }
+}
+
+// TestDiffLinesIsShortest checks random inputs against the edit distance
+// computed by dynamic programming.
+func TestDiffLinesIsShortest(t *testing.T) {
+    rng := rand.New(rand.NewSource(1))
+    randomLines := func() []string {
+        lines := make([]string, rng.Intn(12))
+        for i := range lines {
+            lines[i] = string(rune('a' + rng.Intn(4)))
+        }
+        return lines
+    }
+    for i := 0; i < 2000; i++ {
+        a, b := randomLines(), randomLines()
+        ops := diffLines(a, b)
+
+        var gotA, gotB []string
+        edits := 0
+        for _, op := range ops {
+            if op.Kind != '+' {
+                gotA = append(gotA, op.Line)
+            }
+            if op.Kind != '-' {
+                gotB = append(gotB, op.Line)
+            }
+            if op.Kind != ' ' {
+                edits++
+            }
+        }
+        if strings.Join(gotA, ",") != strings.Join(a, ",") || strings.Join(gotB, ",") != strings.Join(b, ",") {
+            t.Fatalf("diffLines(%q, %q) does not reproduce its inputs: %v", a, b, ops)
+        }
+        if want := editDistance(a, b); edits != want {
+            t.Fatalf("diffLines(%q, %q) made %d edits, want %d", a, b, edits, want)
+        }
+    }
+}
+
+// editDistance returns the number of insertions and deletions turning a into b.
+func editDistance(a, b []string) int {
+    lcs := make([][]int, len(a)+1)
+    for i := range lcs {
+        lcs[i] = make([]int, len(b)+1)
+    }
+    for i := len(a) - 1; i >= 0; i-- {
+        for j := len(b) - 1; j >= 0; j-- {
+            if a[i] == b[j] {
+                lcs[i][j] = lcs[i+1][j+1] + 1
+            } else if lcs[i+1][j] > lcs[i][j+1] {
+                lcs[i][j] = lcs[i+1][j]
+            } else {
+                lcs[i][j] = lcs[i][j+1]
+            }
+        }
+    }
+    return len(a) + len(b) - 2*lcs[0][0]
+}
+
+func TestDiffLinesLargeFileMemory(t *testing.T) {
+    var from, to strings.Builder
+    for i := 0; i < 4000; i++ {
+        fmt.Fprintf(&to, "line %d\n", i)
+        if i%50 != 0 {
+            fmt.Fprintf(&from, "line %d\n", i)
+        } else {
+            fmt.Fprintf(&from, "old %d\n", i)
+        }
+    }
+
+    var before, after runtime.MemStats
+    runtime.ReadMemStats(&before)
+    diff := unifiedDiff("a", "b", from.String(), to.String())
+    runtime.ReadMemStats(&after)
+
+    if !strings.Contains(diff, "-old 50\n+line 50\n") {
+        t.Fatalf("diff is missing an expected change:\n%.500s", diff)
+    }
+    // Copying the search state once per edit allocated hundreds of megabytes.
+    if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+        t.Errorf("unifiedDiff allocated %d bytes for a 4000-line file", allocated)
+    }
+}
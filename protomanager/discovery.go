@@ -0,0 +1,148 @@
+// protomanager/discovery.go
+package protomanager
+
+import (
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "path"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+// defaultDiscoveryInclude matches every .proto file below a package's proto directory.
+var defaultDiscoveryInclude = []string{"**/*.proto"}
+
+// DiscoveryConfig selects which files below a package's proto directory are
+// compiled. Patterns are matched against slash-separated paths relative to
+// that directory; "**" matches any number of directories.
+type DiscoveryConfig struct {
+    Include []string `yaml:"include"` // Defaults to "**/*.proto"
+    Exclude []string `yaml:"exclude"` // Applied after Include
+}
+
+// NoProtoFilesError reports a package whose proto directory yields no files.
+type NoProtoFilesError struct {
+    Package string
+    Dir     string
+    Include []string
+    Exclude []string
+}
+
+// Error implements the error interface.
+func (e *NoProtoFilesError) Error() string {
+    msg := fmt.Sprintf("package '%s' has no proto files in '%s' matching [%s]", e.Package, e.Dir, strings.Join(e.Include, ", "))
+    if len(e.Exclude) > 0 {
+        msg += fmt.Sprintf(" excluding [%s]", strings.Join(e.Exclude, ", "))
+    }
+    return msg
+}
+
+// ProtoPath returns the directory holding a service's proto files.
+func (pm *ProtoManager) ProtoPath(serviceName string) string {
+    return filepath.Join(pm.MicroserviceProtoDir, serviceName, "proto")
+}
+
+// discoveryConfig returns the discovery patterns for a service: its override
+// when set, otherwise the global configuration.
+func (pm *ProtoManager) discoveryConfig(serviceName string) DiscoveryConfig {
+    cfg := pm.Config.Discovery
+    if svc, ok := pm.Config.Services[serviceName]; ok {
+        if len(svc.Discovery.Include) > 0 {
+            cfg.Include = svc.Discovery.Include
+        }
+        if len(svc.Discovery.Exclude) > 0 {
+            cfg.Exclude = svc.Discovery.Exclude
+        }
+    }
+    if len(cfg.Include) == 0 {
+        cfg.Include = defaultDiscoveryInclude
+    }
+    return cfg
+}
+
+// DiscoverProtos walks a service's proto directory recursively and returns
+// the files selected by the discovery patterns, sorted by path. Symlinks to
+// files are matched by their own path; symlinks to directories are skipped.
+// It fails with a *NoProtoFilesError when nothing matches.
+func (pm *ProtoManager) DiscoverProtos(serviceName string) ([]string, error) {
+    dir := pm.ProtoPath(serviceName)
+    cfg := pm.discoveryConfig(serviceName)
+
+    var files []string
+    err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        // Symlinked files are followed; symlinked directories are not walked.
+        if d.Type()&fs.ModeSymlink != 0 {
+            info, err := os.Stat(p)
+            if err != nil || !info.Mode().IsRegular() {
+                return nil
+            }
+        } else if !d.Type().IsRegular() {
+            return nil
+        }
+        rel, err := filepath.Rel(dir, p)
+        if err != nil {
+            return err
+        }
+        name := filepath.ToSlash(rel)
+        if matchesAny(name, cfg.Include) && !(len(cfg.Exclude) > 0 && matchesAny(name, cfg.Exclude)) {
+            files = append(files, p)
+        }
+        return nil
+    })
+    if err != nil && !errors.Is(err, fs.ErrNotExist) {
+        return nil, fmt.Errorf("failed to discover protos for package '%s': %w", serviceName, err)
+    }
+
+    if len(files) == 0 {
+        return nil, &NoProtoFilesError{Package: serviceName, Dir: dir, Include: cfg.Include, Exclude: cfg.Exclude}
+    }
+    sort.Strings(files)
+    return files, nil
+}
+
+// matchGlob reports whether the slash-separated name matches pattern, where
+// "**" as a whole path segment matches zero or more segments and other
+// segments follow path.Match.
+func matchGlob(pattern, name string) bool {
+    return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+    for len(pattern) > 0 {
+        if pattern[0] == "**" {
+            for i := 0; i <= len(name); i++ {
+                if matchSegments(pattern[1:], name[i:]) {
+                    return true
+                }
+            }
+            return false
+        }
+        if len(name) == 0 {
+            return false
+        }
+        if ok, _ := path.Match(pattern[0], name[0]); !ok {
+            return false
+        }
+        pattern, name = pattern[1:], name[1:]
+    }
+    return len(name) == 0
+}
+
+// validateGlobs reports the first pattern matchGlob cannot use, naming field
+// in the error.
+func validateGlobs(field string, patterns []string) error {
+    for _, pattern := range patterns {
+        for _, segment := range strings.Split(pattern, "/") {
+            if _, err := path.Match(segment, ""); err != nil {
+                return fmt.Errorf("invalid pattern '%s' in %s: %w", pattern, field, err)
+            }
+        }
+    }
+    return nil
+}
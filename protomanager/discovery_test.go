@@ -0,0 +1,101 @@
+// protomanager/discovery_test.go
+package protomanager
+
+import (
+    "errors"
+    "os"
+    "path"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+    tests := []struct {
+        pattern, name string
+        match         bool
+    }{
+        {"*.proto", "a.proto", true},
+        {"*.proto", "sub/a.proto", false},
+        {"**/*.proto", "a.proto", true},
+        {"**/*.proto", "sub/deep/a.proto", true},
+        {"**", "sub/a.proto", true},
+        {"sub/**", "sub/a.proto", true},
+        {"sub/**", "sub", true},
+        {"sub/**", "other/a.proto", false},
+        {"sub/**/a.proto", "sub/a.proto", true},
+        {"sub/**/a.proto", "sub/x/y/a.proto", true},
+        {"sub/**/a.proto", "sub/x/y/b.proto", false},
+        {"internal/*", "internal/a.proto", true},
+        {"internal/*", "internal/x/a.proto", false},
+        {"a?.proto", "ab.proto", true},
+        {"[ab].proto", "c.proto", false},
+        {"**/test/**", "x/test/y/a.proto", true},
+        {"**/test/**", "x/testing/a.proto", false},
+    }
+    for _, tt := range tests {
+        if got := matchGlob(tt.pattern, tt.name); got != tt.match {
+            t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.match)
+        }
+    }
+}
+
+func TestLoadConfigRejectsBadPatterns(t *testing.T) {
+    tests := []struct {
+        name   string
+        config string
+        field  string // empty when the config is valid
+    }{
+        {"valid", "discovery:\n  include: [\"**/*.proto\", \"api/[a-z]*.proto\"]\n", ""},
+        {"include", "discovery:\n  include: [\"api/[a-z.proto\"]\n", "discovery.include"},
+        {"exclude", "discovery:\n  exclude: [\"**/\\\\\"]\n", "discovery.exclude"},
+        {"service", "services:\n  billing:\n    discovery:\n      include: [\"[\"]\n", "services.billing.discovery.include"},
+        {"vendor", "vendor:\n  third_party:\n    - name: googleapis\n      files: [\"google/[api/*.proto\"]\n", "vendor.third_party.googleapis.files"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            file := filepath.Join(t.TempDir(), "config.yml")
+            if err := os.WriteFile(file, []byte(tt.config), 0644); err != nil {
+                t.Fatal(err)
+            }
+            _, err := LoadConfig(file)
+            if tt.field == "" {
+                if err != nil {
+                    t.Fatal(err)
+                }
+                return
+            }
+            if !errors.Is(err, path.ErrBadPattern) || !strings.Contains(err.Error(), tt.field) {
+                t.Errorf("LoadConfig() error = %v, want a bad pattern error naming %s", err, tt.field)
+            }
+        })
+    }
+}
+
+func TestDiscoverProtosFollowsSymlinkedFiles(t *testing.T) {
+    pm := newTestProtoManager(t)
+    dir := pm.ProtoPath("billing")
+    shared := t.TempDir()
+    writeProtos(t, dir, map[string]string{"billing.proto": "syntax = \"proto3\";\n"})
+    writeProtos(t, shared, map[string]string{"money.proto": "syntax = \"proto3\";\n", "nested/deep.proto": "syntax = \"proto3\";\n"})
+    links := map[string]string{
+        "money.proto":  filepath.Join(shared, "money.proto"),
+        "nested":       filepath.Join(shared, "nested"),
+        "broken.proto": filepath.Join(shared, "missing.proto"),
+    }
+    for name, target := range links {
+        if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+            t.Skipf("symlinks are not supported: %v", err)
+        }
+    }
+
+    files, err := pm.DiscoverProtos("billing")
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []string{filepath.Join(dir, "billing.proto"), filepath.Join(dir, "money.proto")}
+    if !reflect.DeepEqual(files, want) {
+        t.Errorf("DiscoverProtos() = %v, want %v", files, want)
+    }
+}
@@ -0,0 +1,398 @@
+// protomanager/docs.go
+package protomanager
+
+import (
+    "bytes"
+    "context"
+    "embed"
+    "fmt"
+    htmltemplate "html/template"
+    "os"
+    "path/filepath"
+    "strings"
+    "text/template"
+
+    "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+    // docsDir is the subdirectory of OutputDir holding the API reference.
+    docsDir = "docs"
+    // docsIndexPage is the name of the page linking every other page.
+    docsIndexPage = "index"
+)
+
+//go:embed templates/docs/*.tmpl
+var docsTemplates embed.FS
+
+// DocsConfig configures the API reference written by GenerateDocs.
+type DocsConfig struct {
+    // Formats lists the formats to write: markdown and html.
+    Formats []string `yaml:"formats"`
+    // Regenerate also rewrites the docs whenever code is generated from the
+    // global proto file. It is off by default, leaving docs to `protomanager docs`.
+    Regenerate bool `yaml:"regenerate"`
+}
+
+// docsFormats maps a DocsConfig format to the extension of its pages.
+var docsFormats = map[string]string{
+    "markdown": ".md",
+    "html":     ".html",
+}
+
+// DocsDir returns the directory the API reference is written to.
+func (pm *ProtoManager) DocsDir() string {
+    return filepath.Join(pm.OutputDir, docsDir)
+}
+
+// docPage documents the declarations owned by one registered service, or
+// by the global proto file itself.
+type docPage struct {
+    Name     string
+    Global   bool
+    Metadata ServiceMetadata
+    Files    []string
+    Services []serviceDoc
+    Messages []messageDoc
+    Enums    []enumDoc
+}
+
+type serviceDoc struct {
+    Name, FullName, Comment string
+    Deprecated              bool
+    Methods                 []methodDoc
+}
+
+type methodDoc struct {
+    Name, Comment                    string
+    Deprecated                       bool
+    Request, Response                typeRef
+    ClientStreaming, ServerStreaming bool
+}
+
+type messageDoc struct {
+    Name, FullName, Comment string
+    Deprecated              bool
+    Fields                  []fieldDoc
+}
+
+type fieldDoc struct {
+    Name, Label, Comment string
+    Number               int
+    Type                 typeRef
+    Deprecated           bool
+}
+
+type enumDoc struct {
+    Name, FullName, Comment string
+    Deprecated              bool
+    Values                  []enumValueDoc
+}
+
+type enumValueDoc struct {
+    Name, Comment string
+    Number        int
+    Deprecated    bool
+}
+
+// typeRef names a field, request or response type. Page is set when the type
+// is documented, and the type's FullName is the anchor on that page.
+type typeRef struct {
+    Name, FullName, Page string
+}
+
+// typeLink is a typeRef rendered on a page of extension Ext.
+type typeLink struct {
+    typeRef
+    Ext string
+}
+
+// docsData is the data passed to the docs templates.
+type docsData struct {
+    Ext   string // Page extension of the format being rendered
+    Page  *docPage
+    Pages []*docPage
+}
+
+// GenerateDocs writes browsable API reference docs for every registered
+// service to DocsDir, in each configured format, with a cross-linked index.
+// Declarations in the global proto file are attributed to the service whose
+// block contains them; each service's own proto files are documented on its
+// page too. The docs directory is rewritten wholesale. It returns the paths
+// written.
+func (pm *ProtoManager) GenerateDocs(ctx context.Context) ([]string, error) {
+    for _, format := range pm.Config.Docs.Formats {
+        if _, ok := docsFormats[format]; !ok {
+            return nil, fmt.Errorf("unknown docs format '%s'; use markdown or html", format)
+        }
+    }
+
+    pages, err := pm.collectDocPages(ctx)
+    if err != nil {
+        pm.Logger.Errorf("Failed to collect API docs: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to collect API docs: %v", err)})
+        return nil, err
+    }
+
+    dir := pm.DocsDir()
+    if err := os.RemoveAll(dir); err != nil {
+        return nil, err
+    }
+    if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+        return nil, err
+    }
+
+    var written []string
+    for _, format := range pm.Config.Docs.Formats {
+        ext := docsFormats[format]
+        render, err := docsRenderer(format)
+        if err != nil {
+            return written, err
+        }
+
+        data := docsData{Ext: ext, Pages: pages}
+        targets := map[string]docsData{docsIndexPage: data}
+        for _, page := range pages {
+            targets[page.Name] = docsData{Ext: ext, Page: page, Pages: pages}
+        }
+        for _, name := range sortedKeys(targets) {
+            tmpl := "page"
+            if name == docsIndexPage {
+                tmpl = "index"
+            }
+            var buf bytes.Buffer
+            if err := render(&buf, tmpl, targets[name]); err != nil {
+                return written, fmt.Errorf("failed to render %s docs for '%s': %w", format, name, err)
+            }
+            path := filepath.Join(dir, name+ext)
+            if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+                return written, err
+            }
+            written = append(written, path)
+        }
+    }
+
+    pm.Logger.Infof("API docs written to '%s'", dir)
+    pm.emitEvent(Event{Type: "DocsGenerated", Message: fmt.Sprintf("API docs written to '%s'", dir)})
+    return written, nil
+}
+
+// collectDocPages compiles the global proto file and every service's proto
+// files, and sorts their declarations into pages.
+func (pm *ProtoManager) collectDocPages(ctx context.Context) ([]*docPage, error) {
+    services, err := pm.ProtoRegistry.ListServices()
+    if err != nil {
+        return nil, err
+    }
+
+    declarations, err := pm.serviceDeclarations(ctx, services)
+    if err != nil {
+        return nil, err
+    }
+
+    pages := map[string]*docPage{}
+    for name, metadata := range services {
+        if err := checkServiceName(name); err != nil {
+            return nil, err
+        }
+        pages[name] = &docPage{Name: name, Metadata: metadata}
+    }
+    global := &docPage{Name: globalOwner, Global: true}
+    pageOf := func(d serviceDeclaration) *docPage {
+        if page, ok := pages[d.Service]; ok {
+            return page
+        }
+        return global
+    }
+
+    // Find where every type is documented before linking references to it
+    where := map[protoreflect.FullName]string{}
+    for _, d := range declarations {
+        page := pageOf(d)
+        page.Files = appendUnique(page.Files, d.Desc.ParentFile().Path())
+        for _, name := range declaredTypes(d.Desc) {
+            if _, ok := where[name]; !ok {
+                where[name] = page.Name
+            }
+        }
+    }
+    for _, d := range declarations {
+        pageOf(d).document(d.Desc, where)
+    }
+
+    list := make([]*docPage, 0, len(pages)+1)
+    for _, name := range sortedKeys(pages) {
+        list = append(list, pages[name])
+    }
+    if len(global.Services)+len(global.Messages)+len(global.Enums) > 0 {
+        list = append(list, global)
+    }
+    return list, nil
+}
+
+// document adds desc and its nested types to the page, linking type
+// references through where.
+func (page *docPage) document(desc protoreflect.Descriptor, where map[protoreflect.FullName]string) {
+    ref := func(d protoreflect.Descriptor) typeRef {
+        return typeRef{Name: relativeName(d), FullName: string(d.FullName()), Page: where[d.FullName()]}
+    }
+
+    if service, ok := desc.(protoreflect.ServiceDescriptor); ok {
+        doc := serviceDoc{Name: string(service.Name()), FullName: string(service.FullName()), Comment: descriptorComment(service), Deprecated: isDeprecated(service)}
+        for i := 0; i < service.Methods().Len(); i++ {
+            method := service.Methods().Get(i)
+            doc.Methods = append(doc.Methods, methodDoc{
+                Name:            string(method.Name()),
+                Comment:         descriptorComment(method),
+                Deprecated:      isDeprecated(method),
+                Request:         ref(method.Input()),
+                Response:        ref(method.Output()),
+                ClientStreaming: method.IsStreamingClient(),
+                ServerStreaming: method.IsStreamingServer(),
+            })
+        }
+        page.Services = append(page.Services, doc)
+        return
+    }
+
+    walkTypes(desc, func(d protoreflect.Descriptor) {
+        switch d := d.(type) {
+        case protoreflect.MessageDescriptor:
+            doc := messageDoc{Name: relativeName(d), FullName: string(d.FullName()), Comment: descriptorComment(d), Deprecated: isDeprecated(d)}
+            for i := 0; i < d.Fields().Len(); i++ {
+                field := d.Fields().Get(i)
+                doc.Fields = append(doc.Fields, fieldDoc{
+                    Name:       string(field.Name()),
+                    Label:      fieldLabel(field),
+                    Comment:    descriptorComment(field),
+                    Number:     int(field.Number()),
+                    Type:       fieldType(field, ref),
+                    Deprecated: isDeprecated(field),
+                })
+            }
+            page.Messages = append(page.Messages, doc)
+        case protoreflect.EnumDescriptor:
+            doc := enumDoc{Name: relativeName(d), FullName: string(d.FullName()), Comment: descriptorComment(d), Deprecated: isDeprecated(d)}
+            for i := 0; i < d.Values().Len(); i++ {
+                value := d.Values().Get(i)
+                doc.Values = append(doc.Values, enumValueDoc{
+                    Name:       string(value.Name()),
+                    Comment:    descriptorComment(value),
+                    Number:     int(value.Number()),
+                    Deprecated: isDeprecated(value),
+                })
+            }
+            page.Enums = append(page.Enums, doc)
+        }
+    })
+}
+
+// fieldLabel describes the cardinality of field: repeated, optional or "".
+func fieldLabel(field protoreflect.FieldDescriptor) string {
+    switch {
+    case field.IsMap():
+        return ""
+    case field.IsList():
+        return "repeated"
+    case field.HasOptionalKeyword():
+        return "optional"
+    }
+    return ""
+}
+
+// fieldType returns the type of field, rendering maps as map<key, value>.
+func fieldType(field protoreflect.FieldDescriptor, ref func(protoreflect.Descriptor) typeRef) typeRef {
+    if field.IsMap() {
+        value := fieldType(field.MapValue(), ref)
+        value.Name = fmt.Sprintf("map<%s, %s>", field.MapKey().Kind(), value.Name)
+        return value
+    }
+    switch field.Kind() {
+    case protoreflect.MessageKind, protoreflect.GroupKind:
+        return ref(field.Message())
+    case protoreflect.EnumKind:
+        return ref(field.Enum())
+    }
+    return typeRef{Name: field.Kind().String()}
+}
+
+// relativeName returns the full name of d without its file's package.
+func relativeName(d protoreflect.Descriptor) string {
+    name := string(d.FullName())
+    if pkg := string(d.ParentFile().Package()); pkg != "" {
+        name = strings.TrimPrefix(name, pkg+".")
+    }
+    return name
+}
+
+// descriptorComment returns the leading comment of d, or its trailing
+// comment when there is none, with comment indentation removed.
+func descriptorComment(d protoreflect.Descriptor) string {
+    loc := d.ParentFile().SourceLocations().ByDescriptor(d)
+    comment := loc.LeadingComments
+    if strings.TrimSpace(comment) == "" {
+        comment = loc.TrailingComments
+    }
+    var lines []string
+    for _, line := range strings.Split(comment, "\n") {
+        line = strings.TrimPrefix(strings.TrimRight(line, " \t"), " ")
+        // Block markers directly above a declaration read as its comment
+        if strings.HasPrefix(line, strings.TrimPrefix(serviceBeginMarker, "// ")) || strings.HasPrefix(line, strings.TrimPrefix(serviceEndMarker, "// ")) {
+            continue
+        }
+        if strings.HasPrefix(line, lintIgnoreDirective) {
+            continue
+        }
+        lines = append(lines, line)
+    }
+    return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// isDeprecated reports whether d sets the deprecated option.
+func isDeprecated(d protoreflect.Descriptor) bool {
+    options, ok := d.Options().(interface{ GetDeprecated() bool })
+    return ok && options.GetDeprecated()
+}
+
+// appendUnique appends item to list unless it is already there.
+func appendUnique(list []string, item string) []string {
+    for _, existing := range list {
+        if existing == item {
+            return list
+        }
+    }
+    return append(list, item)
+}
+
+// docsFuncs are the helper functions available to the docs templates.
+var docsFuncs = template.FuncMap{
+    "join":     strings.Join,
+    "typeData": func(ref typeRef, ext string) typeLink { return typeLink{ref, ext} },
+    // cell escapes text for a Markdown table cell
+    "cell": func(s string) string {
+        return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", "<br>")
+    },
+}
+
+// docsRenderer returns a function executing the named template of format.
+// Markdown uses text/template and HTML uses html/template, which escapes
+// comments and names.
+func docsRenderer(format string) (func(buf *bytes.Buffer, name string, data docsData) error, error) {
+    pattern := "templates/docs/*" + docsFormats[format] + ".tmpl"
+    if format == "html" {
+        tmpl, err := htmltemplate.New(format).Funcs(htmltemplate.FuncMap(docsFuncs)).ParseFS(docsTemplates, pattern)
+        if err != nil {
+            return nil, err
+        }
+        return func(buf *bytes.Buffer, name string, data docsData) error {
+            return tmpl.ExecuteTemplate(buf, name, data)
+        }, nil
+    }
+    tmpl, err := template.New(format).Funcs(docsFuncs).ParseFS(docsTemplates, pattern)
+    if err != nil {
+        return nil, err
+    }
+    return func(buf *bytes.Buffer, name string, data docsData) error {
+        return tmpl.ExecuteTemplate(buf, name, data)
+    }, nil
+}
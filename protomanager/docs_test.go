@@ -0,0 +1,115 @@
+// protomanager/docs_test.go
+package protomanager
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestGenerateDocsPage(t *testing.T) {
+    pm := newTestProtoManager(t)
+    pm.Config.Docs.Formats = []string{"markdown"}
+    if err := pm.ProtoRegistry.RegisterService("checkout", ServiceMetadata{Domain: "pay", Version: "v1"}); err != nil {
+        t.Fatal(err)
+    }
+    writeGlobalProto(t, pm, "health", "checkout")
+
+    written, err := pm.GenerateDocs(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []string{filepath.Join(pm.DocsDir(), "checkout.md"), filepath.Join(pm.DocsDir(), "index.md")}
+    if len(written) != len(want) || written[0] != want[0] || written[1] != want[1] {
+        t.Fatalf("GenerateDocs() wrote %v, want %v", written, want)
+    }
+    got, err := os.ReadFile(want[0])
+    if err != nil {
+        t.Fatal(err)
+    }
+    if string(got) != checkoutDocsPage {
+        t.Errorf("checkout.md =\n%s\nwant\n%s", got, checkoutDocsPage)
+    }
+}
+
+func TestReservedServiceNames(t *testing.T) {
+    for _, name := range []string{"global", "index"} {
+        t.Run(name, func(t *testing.T) {
+            pm := newTestProtoManager(t)
+            if err := pm.RegisterMicroservice(context.Background(), name, "pay", "v1"); err == nil {
+                t.Fatalf("RegisterMicroservice(%q) succeeded", name)
+            }
+            if services, _ := pm.ProtoRegistry.ListServices(); len(services) != 0 {
+                t.Errorf("rejected service was registered: %v", services)
+            }
+
+            // Names registered before they were reserved fail docs generation.
+            if err := pm.ProtoRegistry.RegisterService(name, ServiceMetadata{}); err != nil {
+                t.Fatal(err)
+            }
+            writeGlobalProto(t, pm, "health")
+            if _, err := pm.GenerateDocs(context.Background()); err == nil {
+                t.Error("GenerateDocs succeeded with a reserved service name")
+            }
+        })
+    }
+}
+
+// checkoutDocsPage is the markdown page of a service scaffolded from the
+// health template.
+const checkoutDocsPage = `# checkout
+
+[Index](index.md)
+
+- Domain: pay
+- Version: v1
+- Files: ` + "`" + `global.proto` + "`" + `
+
+## Services
+
+<a id="protomanager.CheckoutService"></a>
+### CheckoutService
+
+CheckoutService health checks for the pay domain.
+
+| Method | Request | Response | Description |
+| --- | --- | --- | --- |
+| Check | [CheckoutHealthCheckRequest](checkout.md#protomanager.CheckoutHealthCheckRequest) | [CheckoutHealthCheckResponse](checkout.md#protomanager.CheckoutHealthCheckResponse) | Check returns the current serving status. |
+| Watch | [CheckoutHealthCheckRequest](checkout.md#protomanager.CheckoutHealthCheckRequest) | stream [CheckoutHealthCheckResponse](checkout.md#protomanager.CheckoutHealthCheckResponse) | Watch streams the serving status whenever it changes. |
+
+## Messages
+
+<a id="protomanager.CheckoutHealthCheckRequest"></a>
+### CheckoutHealthCheckRequest
+
+CheckoutHealthCheckRequest names the service to check.
+
+| Field | Number | Type | Description |
+| --- | --- | --- | --- |
+| service | 1 | ` + "`" + `string` + "`" + ` |  |
+
+<a id="protomanager.CheckoutHealthCheckResponse"></a>
+### CheckoutHealthCheckResponse
+
+CheckoutHealthCheckResponse reports a serving status.
+
+| Field | Number | Type | Description |
+| --- | --- | --- | --- |
+| status | 1 | [CheckoutHealthCheckResponse.ServingStatus](checkout.md#protomanager.CheckoutHealthCheckResponse.ServingStatus) |  |
+
+## Enums
+
+<a id="protomanager.CheckoutHealthCheckResponse.ServingStatus"></a>
+### CheckoutHealthCheckResponse.ServingStatus
+
+ServingStatus is the health of a service.
+
+| Value | Number | Description |
+| --- | --- | --- |
+| SERVING_STATUS_UNSPECIFIED | 0 |  |
+| SERVING_STATUS_SERVING | 1 |  |
+| SERVING_STATUS_NOT_SERVING | 2 |  |
+
+`
+
@@ -0,0 +1,304 @@
+// protomanager/dryrun.go
+package protomanager
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// FileChange describes how a single file would change.
+type FileChange struct {
+    Path   string
+    Status string // "added", "removed" or "modified"
+}
+
+// DryRunReport describes what an operation would change without applying it.
+type DryRunReport struct {
+    Files []FileChange
+    Diff  string
+}
+
+// Summary returns a file-level summary of the changes, one file per line.
+func (r *DryRunReport) Summary() string {
+    if len(r.Files) == 0 {
+        return "no changes"
+    }
+
+    var added, removed, modified int
+    var sb strings.Builder
+    for _, f := range r.Files {
+        switch f.Status {
+        case "added":
+            added++
+            sb.WriteString("  A ")
+        case "removed":
+            removed++
+            sb.WriteString("  D ")
+        default:
+            modified++
+            sb.WriteString("  M ")
+        }
+        sb.WriteString(f.Path + "\n")
+    }
+    return fmt.Sprintf("%d file(s) changed: %d added, %d removed, %d modified\n%s", len(r.Files), added, removed, modified, sb.String())
+}
+
+// DryRun runs fn against a scratch copy of the ProtoManager and reports what
+// it would change in the global proto file and OutputDir. GlobalProtoPath,
+// MicroserviceProtoDir, OutputDir, the registry, the configuration, the
+// vendor cache and the toolchain directory are left untouched.
+func (pm *ProtoManager) DryRun(fn func(shadow *ProtoManager) error) (*DryRunReport, error) {
+    scratch, err := os.MkdirTemp("", "protomanager-dryrun-*")
+    if err != nil {
+        return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+    }
+    defer os.RemoveAll(scratch)
+
+    shadow, err := pm.shadow(scratch)
+    if err != nil {
+        return nil, err
+    }
+
+    pm.Logger.Infof("Dry run: computing changes in scratch directory '%s'", scratch)
+    if err := fn(shadow); err != nil {
+        return nil, err
+    }
+
+    report := &DryRunReport{}
+    var diff strings.Builder
+
+    before, err := readFileIfExists(pm.GlobalProtoPath)
+    if err != nil {
+        return nil, err
+    }
+    after, err := readFileIfExists(shadow.GlobalProtoPath)
+    if err != nil {
+        return nil, err
+    }
+    if change, text := compareFile(pm.GlobalProtoPath, before, after); change != nil {
+        report.Files = append(report.Files, *change)
+        diff.WriteString(text)
+    }
+
+    changes, text, err := compareTrees(pm.OutputDir, shadow.OutputDir)
+    if err != nil {
+        return nil, err
+    }
+    report.Files = append(report.Files, changes...)
+    diff.WriteString(text)
+
+    report.Diff = diff.String()
+    return report, nil
+}
+
+// shadow returns a ProtoManager whose files, registry and configuration are
+// copies living under scratch. The vendor cache and toolchain directory are
+// copied too, so vendoring or installing tools in a dry run cannot touch the
+// real ones.
+func (pm *ProtoManager) shadow(scratch string) (*ProtoManager, error) {
+    pm.mu.Lock()
+    defer pm.mu.Unlock()
+
+    services, err := pm.ProtoRegistry.ListServices()
+    if err != nil {
+        return nil, fmt.Errorf("failed to snapshot registry: %w", err)
+    }
+    registry := NewInternalProtoRegistry()
+    for name, metadata := range services {
+        registry.services[name] = metadata
+    }
+
+    config := *pm.Config
+    if config.Vendor.CacheDir != "" {
+        config.Vendor.CacheDir = filepath.Join(scratch, "vendor")
+        if err := copyDir(pm.Config.Vendor.CacheDir, config.Vendor.CacheDir); err != nil {
+            return nil, fmt.Errorf("failed to copy vendor cache: %w", err)
+        }
+    }
+    if config.Toolchain.Dir != "" {
+        config.Toolchain.Dir = filepath.Join(scratch, "toolchain")
+        if err := copyDir(pm.Config.Toolchain.Dir, config.Toolchain.Dir); err != nil {
+            return nil, fmt.Errorf("failed to copy toolchain directory: %w", err)
+        }
+    }
+
+    shadow := &ProtoManager{
+        ProtoRegistry:        registry,
+        GlobalProtoPath:      filepath.Join(scratch, "global", filepath.Base(pm.GlobalProtoPath)),
+        MicroserviceProtoDir: filepath.Join(scratch, "microservices"),
+        OutputDir:            filepath.Join(scratch, "output"),
+        Config:               &config,
+        Generator:            pm.Generator,
+        Force:                pm.Force,
+        Logger:               pm.Logger,
+    }
+
+    if err := os.MkdirAll(filepath.Dir(shadow.GlobalProtoPath), os.ModePerm); err != nil {
+        return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+    }
+    if err := copyFile(pm.GlobalProtoPath, shadow.GlobalProtoPath); err != nil && !os.IsNotExist(err) {
+        return nil, fmt.Errorf("failed to copy global proto file: %w", err)
+    }
+    if err := copyDir(pm.MicroserviceProtoDir, shadow.MicroserviceProtoDir); err != nil {
+        return nil, fmt.Errorf("failed to copy microservice proto directory: %w", err)
+    }
+    if err := copyDir(pm.OutputDir, shadow.OutputDir); err != nil {
+        return nil, fmt.Errorf("failed to copy output directory: %w", err)
+    }
+    return shadow, nil
+}
+
+// compareTrees diffs every file under the before and after directories.
+// Paths in the result are reported relative to before.
+func compareTrees(before, after string) ([]FileChange, string, error) {
+    beforeFiles, err := listFiles(before)
+    if err != nil {
+        return nil, "", err
+    }
+    afterFiles, err := listFiles(after)
+    if err != nil {
+        return nil, "", err
+    }
+
+    all := make(map[string]bool, len(beforeFiles)+len(afterFiles))
+    for rel := range beforeFiles {
+        all[rel] = true
+    }
+    for rel := range afterFiles {
+        all[rel] = true
+    }
+
+    var changes []FileChange
+    var diff strings.Builder
+    for _, rel := range sortedKeys(all) {
+        if rel == generationCacheFile {
+            continue
+        }
+        var oldContent, newContent []byte
+        if beforeFiles[rel] {
+            if oldContent, err = os.ReadFile(filepath.Join(before, rel)); err != nil {
+                return nil, "", err
+            }
+        }
+        if afterFiles[rel] {
+            if newContent, err = os.ReadFile(filepath.Join(after, rel)); err != nil {
+                return nil, "", err
+            }
+        }
+        if change, text := compareFile(filepath.Join(before, rel), oldContent, newContent); change != nil {
+            changes = append(changes, *change)
+            diff.WriteString(text)
+        }
+    }
+    return changes, diff.String(), nil
+}
+
+// compareFile classifies the change between two versions of path, where a nil
+// slice means the file does not exist, and renders its unified diff.
+func compareFile(path string, before, after []byte) (*FileChange, string) {
+    path = filepath.ToSlash(filepath.Clean(path))
+    switch {
+    case before == nil && after == nil:
+        return nil, ""
+    case before == nil:
+        change := &FileChange{Path: path, Status: "added"}
+        return change, fileDiff("/dev/null", "b/"+path, nil, after)
+    case after == nil:
+        change := &FileChange{Path: path, Status: "removed"}
+        return change, fileDiff("a/"+path, "/dev/null", before, nil)
+    case bytes.Equal(before, after):
+        return nil, ""
+    default:
+        change := &FileChange{Path: path, Status: "modified"}
+        return change, fileDiff("a/"+path, "b/"+path, before, after)
+    }
+}
+
+// fileDiff renders a unified diff, or a one-line note for binary content.
+func fileDiff(fromName, toName string, before, after []byte) string {
+    if bytes.IndexByte(before, 0) >= 0 || bytes.IndexByte(after, 0) >= 0 {
+        return fmt.Sprintf("Binary files %s and %s differ\n", fromName, toName)
+    }
+    return unifiedDiff(fromName, toName, string(before), string(after))
+}
+
+// readFileIfExists returns the content of path, or nil if it does not exist.
+func readFileIfExists(path string) ([]byte, error) {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    return data, err
+}
+
+// listFiles returns the set of regular files under root, relative to root.
+// A missing root yields an empty set.
+func listFiles(root string) (map[string]bool, error) {
+    files := make(map[string]bool)
+    err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+        if err != nil {
+            if os.IsNotExist(err) && path == root {
+                return filepath.SkipDir
+            }
+            return err
+        }
+        if d.Type().IsRegular() {
+            rel, err := filepath.Rel(root, path)
+            if err != nil {
+                return err
+            }
+            files[filepath.ToSlash(rel)] = true
+        }
+        return nil
+    })
+    return files, err
+}
+
+// copyDir recursively copies the regular files under src into dst.
+// A missing src is treated as an empty directory.
+func copyDir(src, dst string) error {
+    files, err := listFiles(src)
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+        return err
+    }
+    for rel := range files {
+        if err := copyFile(filepath.Join(src, rel), filepath.Join(dst, rel)); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// copyFile copies src to dst, creating dst's parent directories and keeping its mode.
+func copyFile(src, dst string) error {
+    in, err := os.Open(src)
+    if err != nil {
+        return err
+    }
+    defer in.Close()
+
+    info, err := in.Stat()
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+        return err
+    }
+    out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+    if err != nil {
+        return err
+    }
+    if _, err := io.Copy(out, in); err != nil {
+        out.Close()
+        return err
+    }
+    return out.Close()
+}
@@ -0,0 +1,58 @@
+// protomanager/dryrun_test.go
+package protomanager
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// TestDryRunIsolatesCaches checks that a dry run works on copies of the
+// vendor cache and toolchain directory and leaves the configuration alone.
+func TestDryRunIsolatesCaches(t *testing.T) {
+    pm := newTestProtoManager(t)
+    dir := t.TempDir()
+    pm.Config.Vendor.CacheDir = filepath.Join(dir, "vendor")
+    pm.Config.Toolchain.Dir = filepath.Join(dir, "toolchain")
+    vendored := filepath.Join(pm.Config.Vendor.CacheDir, "google", "api", "http.proto")
+    installed := filepath.Join(pm.Config.Toolchain.Dir, "protoc")
+    for _, path := range []string{vendored, installed} {
+        if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(path, []byte("original"), 0755); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    _, err := pm.DryRun(func(shadow *ProtoManager) error {
+        if shadow.Config == pm.Config {
+            t.Error("shadow shares the configuration")
+        }
+        if _, err := os.Stat(filepath.Join(shadow.Config.Vendor.CacheDir, "google", "api", "http.proto")); err != nil {
+            t.Errorf("vendor cache was not copied: %v", err)
+        }
+        for _, dir := range []string{shadow.Config.Vendor.CacheDir, shadow.Config.Toolchain.Dir} {
+            if err := os.RemoveAll(dir); err != nil {
+                return err
+            }
+            if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+                return err
+            }
+        }
+        shadow.Config.Vendor.CacheDir = "changed"
+        return nil
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if pm.Config.Vendor.CacheDir != filepath.Join(dir, "vendor") {
+        t.Errorf("dry run changed vendor.cache_dir to '%s'", pm.Config.Vendor.CacheDir)
+    }
+    for _, path := range []string{vendored, installed} {
+        if data, err := os.ReadFile(path); err != nil || string(data) != "original" {
+            t.Errorf("dry run modified '%s': %q, %v", path, data, err)
+        }
+    }
+}
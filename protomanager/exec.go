@@ -0,0 +1,83 @@
+// protomanager/exec.go
+package protomanager
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os/exec"
+    "path/filepath"
+    "time"
+)
+
+// commandWaitDelay bounds how long Wait waits for output pipes once a
+// cancelled command has been killed.
+const commandWaitDelay = 5 * time.Second
+
+// TimeoutConfig bounds how long external commands may run. A zero duration
+// leaves the command bounded only by cancellation.
+type TimeoutConfig struct {
+    Protoc time.Duration `yaml:"protoc"` // Each protoc run
+    Plugin time.Duration `yaml:"plugin"` // Each plugin run by the native generator
+    Hook   time.Duration `yaml:"hook"`   // Each post-generation hook
+    Git    time.Duration `yaml:"git"`    // Each git clone, commit or push
+}
+
+// Command is an external command that is killed, together with every process
+// it started, when its context is cancelled or its timeout elapses. Run it
+// with Run, Output or CombinedOutput so the timeout is released.
+type Command struct {
+    *exec.Cmd
+    ctx     context.Context
+    cancel  context.CancelFunc
+    timeout time.Duration
+}
+
+// NewCommand returns a Command running name with args under ctx. A zero
+// timeout only follows ctx.
+func NewCommand(ctx context.Context, timeout time.Duration, name string, args ...string) *Command {
+    cancel := context.CancelFunc(func() {})
+    if timeout > 0 {
+        ctx, cancel = context.WithTimeout(ctx, timeout)
+    }
+    cmd := exec.CommandContext(ctx, name, args...)
+    killProcessGroup(cmd)
+    cmd.WaitDelay = commandWaitDelay
+    return &Command{Cmd: cmd, ctx: ctx, cancel: cancel, timeout: timeout}
+}
+
+// Run starts the command and waits for it to finish.
+func (c *Command) Run() error {
+    defer c.cancel()
+    return c.wrap(c.Cmd.Run())
+}
+
+// Output runs the command and returns its standard output.
+func (c *Command) Output() ([]byte, error) {
+    defer c.cancel()
+    output, err := c.Cmd.Output()
+    return output, c.wrap(err)
+}
+
+// CombinedOutput runs the command and returns its standard output and standard error.
+func (c *Command) CombinedOutput() ([]byte, error) {
+    defer c.cancel()
+    output, err := c.Cmd.CombinedOutput()
+    return output, c.wrap(err)
+}
+
+// wrap explains a failure caused by cancellation or the timeout rather than
+// by the command itself.
+func (c *Command) wrap(err error) error {
+    if err == nil {
+        return nil
+    }
+    name := filepath.Base(c.Path)
+    switch ctxErr := c.ctx.Err(); {
+    case errors.Is(ctxErr, context.DeadlineExceeded) && c.timeout > 0:
+        return fmt.Errorf("%s timed out after %s: %w", name, c.timeout, ctxErr)
+    case ctxErr != nil:
+        return fmt.Errorf("%s was stopped: %w", name, ctxErr)
+    }
+    return err
+}
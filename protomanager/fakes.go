@@ -0,0 +1,291 @@
+// protomanager/fakes.go
+package protomanager
+
+import (
+    "bytes"
+    "context"
+    "embed"
+    "fmt"
+    "go/format"
+    "os"
+    "path"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "text/template"
+
+    "google.golang.org/protobuf/reflect/protoreflect"
+    "google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+    // fakePackageSuffix names the package holding the fakes of a Go
+    // package, e.g. billingfake next to billing.
+    fakePackageSuffix = "fake"
+    // fakeFileSuffix ends the name of every generated fake.
+    fakeFileSuffix = "_fake.go"
+    // grpcModule is the grpc-go module the fakes build on.
+    grpcModule = "google.golang.org/grpc"
+)
+
+//go:embed templates/fakes/*.tmpl
+var fakeTemplates embed.FS
+
+// fakeImportNames are identifiers the fake template already uses, so
+// message packages are never imported under them.
+var fakeImportNames = map[string]bool{
+    "context": true, "io": true, "net": true, "sync": true, "grpc": true, "codes": true,
+    "insecure": true, "status": true, "bufconn": true, "proto": true,
+}
+
+// FakesConfig configures the Go fakes written next to generated Go code.
+type FakesConfig struct {
+    // Enabled writes an in-memory fake server for every gRPC service of Go
+    // targets. The fakes need google.golang.org/grpc v1.63.0 or later.
+    Enabled bool `yaml:"enabled"`
+}
+
+// checkFakesGRPC fails when the scaffolded go.mod pins a grpc-go release
+// older than v1.63.0, the first with grpc.NewClient, which the fakes call.
+func (pm *ProtoManager) checkFakesGRPC() error {
+    pinned, ok := pm.Config.GoModule.Require[grpcModule]
+    if pm.Config.GoModule.Mode == "" || !ok {
+        return nil
+    }
+    v, err := parseArtifactVersion(pinned)
+    if err != nil {
+        return fmt.Errorf("go_module.require: %s: %w", grpcModule, err)
+    }
+    if v.Major < 1 || (v.Major == 1 && v.Minor < 63) {
+        return fmt.Errorf("fakes need %s v1.63.0 or later, but go_module.require pins %s; raise it or set fakes.enabled: false", grpcModule, pinned)
+    }
+    return nil
+}
+
+// fakeData is the data passed to the fake server template.
+type fakeData struct {
+    Source           string // Proto file declaring the service
+    Package          string // Go package of the fake
+    PB               string // Import alias of the service's Go package
+    Imports          []fakeImport
+    Service          string // Go name of the service
+    Methods          []fakeMethod
+    HasClientStreams bool
+}
+
+type fakeImport struct {
+    Alias, Path string
+}
+
+type fakeMethod struct {
+    Name, FullName  string
+    Input, Output   string // Qualified Go types of the messages
+    ClientStreaming bool
+    ServerStreaming bool
+}
+
+// generateFakes writes a fake server for every gRPC service compiled by req
+// into a "<package>fake" directory next to its generated _grpc.pb.go file.
+// Each fake implements the service's server interface with programmable
+// responses, records the requests it receives and serves itself over an
+// in-process bufconn listener. It returns the paths written.
+func (pm *ProtoManager) generateFakes(ctx context.Context, target OutputTarget, req GenerateRequest, generated []string) ([]string, error) {
+    if !pm.Config.Fakes.Enabled || !goLanguages[target.Language] {
+        return nil, nil
+    }
+    if err := pm.checkFakesGRPC(); err != nil {
+        return nil, err
+    }
+    _, compiled, err := compileProtos(ctx, req.IncludePaths, req.Files)
+    if err != nil {
+        return nil, err
+    }
+    tmpl, err := template.ParseFS(fakeTemplates, "templates/fakes/server.go.tmpl")
+    if err != nil {
+        return nil, err
+    }
+
+    var written []string
+    for _, file := range compiled {
+        if file.Services().Len() == 0 {
+            continue
+        }
+        importPath, pkg := goPackage(file)
+        grpcFile := findGeneratedFile(generated, file.Path(), importPath)
+        if importPath == "" || grpcFile == "" {
+            pm.Logger.Debugf("No generated gRPC code for '%s'; skipping fakes", file.Path())
+            continue
+        }
+        dir := filepath.Join(filepath.Dir(grpcFile), pkg+fakePackageSuffix)
+
+        for i := 0; i < file.Services().Len(); i++ {
+            service := file.Services().Get(i)
+            if service.Methods().Len() == 0 {
+                continue
+            }
+            data := newFakeData(file, service, importPath, pkg)
+
+            var buf bytes.Buffer
+            if err := tmpl.Execute(&buf, data); err != nil {
+                return written, fmt.Errorf("failed to render fake for '%s': %w", service.FullName(), err)
+            }
+            source, err := format.Source(buf.Bytes())
+            if err != nil {
+                return written, fmt.Errorf("failed to format fake for '%s': %w", service.FullName(), err)
+            }
+
+            out := filepath.Join(dir, snakeCase(string(service.Name()))+fakeFileSuffix)
+            if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+                return written, err
+            }
+            if err := os.WriteFile(out, source, 0644); err != nil {
+                return written, err
+            }
+            written = append(written, out)
+        }
+    }
+
+    if len(written) > 0 {
+        pm.Logger.Infof("Wrote %d fake server(s) for '%s'", len(written), target.key())
+        pm.emitEvent(Event{Type: "FakesGenerated", Message: fmt.Sprintf("Wrote %d fake server(s) for '%s'", len(written), target.key())})
+    }
+    return written, nil
+}
+
+// newFakeData describes service for the fake template, importing the Go
+// packages of request and response types declared elsewhere.
+func newFakeData(file protoreflect.FileDescriptor, service protoreflect.ServiceDescriptor, importPath, pkg string) fakeData {
+    data := fakeData{
+        Source:  file.Path(),
+        Package: pkg + fakePackageSuffix,
+        Service: goCamelCase(string(service.Name())),
+    }
+
+    aliases := map[string]string{}
+    alias := func(p, name string) string {
+        if a, ok := aliases[p]; ok {
+            return a
+        }
+        a := name
+        for n := 2; fakeImportNames[a] || a == data.Package || aliasTaken(aliases, a); n++ {
+            a = name + strconv.Itoa(n)
+        }
+        aliases[p] = a
+        data.Imports = append(data.Imports, fakeImport{Alias: a, Path: p})
+        return a
+    }
+    data.PB = alias(importPath, pkg)
+
+    goType := func(md protoreflect.MessageDescriptor) string {
+        p, name := goPackage(md.ParentFile())
+        ident := goCamelCase(strings.TrimPrefix(string(md.FullName()), string(md.ParentFile().Package())+"."))
+        return alias(p, name) + "." + ident
+    }
+    for i := 0; i < service.Methods().Len(); i++ {
+        method := service.Methods().Get(i)
+        data.Methods = append(data.Methods, fakeMethod{
+            Name:            goCamelCase(string(method.Name())),
+            FullName:        fmt.Sprintf("/%s/%s", service.FullName(), method.Name()),
+            Input:           goType(method.Input()),
+            Output:          goType(method.Output()),
+            ClientStreaming: method.IsStreamingClient(),
+            ServerStreaming: method.IsStreamingServer(),
+        })
+        data.HasClientStreams = data.HasClientStreams || method.IsStreamingClient()
+    }
+    return data
+}
+
+// aliasTaken reports whether alias is already used for another import.
+func aliasTaken(aliases map[string]string, alias string) bool {
+    for _, a := range aliases {
+        if a == alias {
+            return true
+        }
+    }
+    return false
+}
+
+// findGeneratedFile returns the _grpc.pb.go file generated for the proto
+// file name, in source-relative or import-path layout, or "".
+func findGeneratedFile(generated []string, name, importPath string) string {
+    base := strings.TrimSuffix(path.Base(name), ".proto") + "_grpc.pb.go"
+    candidates := []string{
+        strings.TrimSuffix(name, ".proto") + "_grpc.pb.go",
+        path.Join(importPath, base),
+    }
+    var match string
+    for _, file := range generated {
+        slashed := filepath.ToSlash(file)
+        if path.Base(slashed) != base {
+            continue
+        }
+        for _, candidate := range candidates {
+            if strings.HasSuffix(slashed, "/"+candidate) {
+                return file
+            }
+        }
+        if match == "" {
+            match = file
+        }
+    }
+    return match
+}
+
+// goPackage returns the Go import path and package name protoc-gen-go
+// uses for file, from its go_package option.
+func goPackage(file protoreflect.FileDescriptor) (importPath, name string) {
+    options, _ := file.Options().(*descriptorpb.FileOptions)
+    importPath = options.GetGoPackage()
+    if importPath == "" {
+        return "", ""
+    }
+    if i := strings.Index(importPath, ";"); i >= 0 {
+        importPath, name = importPath[:i], importPath[i+1:]
+    }
+    if name == "" {
+        name = path.Base(importPath)
+    }
+    name = strings.Map(func(r rune) rune {
+        if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+            return r
+        }
+        return '_'
+    }, name)
+    if name != "" && '0' <= name[0] && name[0] <= '9' {
+        name = "_" + name
+    }
+    return importPath, name
+}
+
+// goCamelCase converts a proto name to the Go identifier protoc-gen-go
+// generates for it.
+func goCamelCase(s string) string {
+    isLower := func(c byte) bool { return 'a' <= c && c <= 'z' }
+    var b []byte
+    for i := 0; i < len(s); i++ {
+        c := s[i]
+        switch {
+        case c == '.' && i+1 < len(s) && isLower(s[i+1]):
+            // Skip '.' in ".{{lowercase}}"
+        case c == '.':
+            b = append(b, '_')
+        case c == '_' && (i == 0 || s[i-1] == '.'):
+            // Start with a capital letter
+            b = append(b, 'X')
+        case c == '_' && i+1 < len(s) && isLower(s[i+1]):
+            // Skip '_' in "_{{lowercase}}"
+        case '0' <= c && c <= '9':
+            b = append(b, c)
+        default:
+            if isLower(c) {
+                c -= 'a' - 'A'
+            }
+            b = append(b, c)
+            for ; i+1 < len(s) && isLower(s[i+1]); i++ {
+                b = append(b, s[i+1])
+            }
+        }
+    }
+    return string(b)
+}
@@ -0,0 +1,32 @@
+// protomanager/fakes_test.go
+package protomanager
+
+import "testing"
+
+func TestCheckFakesGRPC(t *testing.T) {
+    tests := []struct {
+        mode    string
+        pinned  string
+        wantErr bool
+    }{
+        {GoModulePerPackage, "v1.64.0", false},
+        {GoModulePerPackage, "v1.63.0", false},
+        {GoModulePerPackage, "v2.0.0", false},
+        {GoModulePerPackage, "v1.62.1", true},
+        {GoModulePerPackage, "v0.9.0", true},
+        {GoModulePerPackage, "latest", true},
+        {GoModulePerPackage, "", false},
+        {"", "v1.50.0", false},
+    }
+    for _, tt := range tests {
+        pm := newTestProtoManager(t)
+        pm.Config.GoModule.Mode = tt.mode
+        pm.Config.GoModule.Require = map[string]string{}
+        if tt.pinned != "" {
+            pm.Config.GoModule.Require[grpcModule] = tt.pinned
+        }
+        if err := pm.checkFakesGRPC(); (err != nil) != tt.wantErr {
+            t.Errorf("mode %q, grpc %q: checkFakesGRPC() = %v, want error %v", tt.mode, tt.pinned, err, tt.wantErr)
+        }
+    }
+}
@@ -0,0 +1,244 @@
+// protomanager/file_options.go
+package protomanager
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "regexp"
+    "strconv"
+    "strings"
+    "text/template"
+)
+
+var (
+    packagePattern = regexp.MustCompile(`^\s*package\s+([\w.]+)\s*;`)
+    syntaxPattern  = regexp.MustCompile(`^\s*(syntax|edition)\s*=`)
+    optionPattern  = regexp.MustCompile(`^option\s+([\w.()]+)\s*=`)
+)
+
+// unquotedFileOptions are file options whose values are not string literals.
+var unquotedFileOptions = map[string]bool{
+    "cc_enable_arenas":       true,
+    "cc_generic_services":    true,
+    "deprecated":             true,
+    "java_generic_services":  true,
+    "java_multiple_files":    true,
+    "java_string_check_utf8": true,
+    "optimize_for":           true,
+    "py_generic_services":    true,
+}
+
+// FileOptionsData is the data passed to file option templates.
+type FileOptionsData struct {
+    Module  string // Config.Module
+    Package string // Proto package declared by the file
+    Service string // Registered service name; empty for the global proto file
+    Domain  string // ServiceMetadata.Domain; empty for the global proto file
+    Version string // ServiceMetadata.Version; empty for the global proto file
+}
+
+// fileOptionTemplates returns the option templates that apply to serviceName,
+// or to the global proto file when serviceName is empty.
+func (pm *ProtoManager) fileOptionTemplates(serviceName string) map[string]string {
+    options := make(map[string]string, len(pm.Config.FileOptions))
+    for name, value := range pm.Config.FileOptions {
+        options[name] = value
+    }
+
+    overrides := pm.Config.GlobalFileOptions
+    if serviceName != "" {
+        overrides = pm.Config.Services[serviceName].FileOptions
+    }
+    for name, value := range overrides {
+        options[name] = value
+    }
+    return options
+}
+
+// renderFileOptions renders the option templates for a file. Options that
+// render to an empty value are kept empty, so injectFileOptions removes them.
+func renderFileOptions(templates map[string]string, data FileOptionsData) (map[string]string, error) {
+    options := make(map[string]string, len(templates))
+    for name, text := range templates {
+        tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+        if err != nil {
+            return nil, fmt.Errorf("failed to parse file option '%s': %w", name, err)
+        }
+        var sb strings.Builder
+        if err := tmpl.Execute(&sb, data); err != nil {
+            return nil, fmt.Errorf("failed to render file option '%s': %w", name, err)
+        }
+        options[name] = strings.TrimSpace(sb.String())
+    }
+    return options, nil
+}
+
+// injectFileOptions replaces the top-level declarations of the given options in
+// a proto file with freshly rendered ones, placed after the package and import
+// statements. Options with an empty value are removed.
+// Running it twice with the same options yields the same content.
+func injectFileOptions(content string, options map[string]string) string {
+    if len(options) == 0 {
+        return content
+    }
+
+    lines := strings.Split(content, "\n")
+    kept := make([]string, 0, len(lines))
+    anchor := -1
+    depth := 0
+    inBlock := false
+    for _, line := range lines {
+        if depth == 0 {
+            trimmed := strings.TrimSpace(line)
+            if strings.HasPrefix(trimmed, serviceBeginMarker) {
+                inBlock = true
+            } else if strings.HasPrefix(trimmed, serviceEndMarker) {
+                inBlock = false
+            }
+            if m := optionPattern.FindStringSubmatch(trimmed); m != nil {
+                if _, managed := options[m[1]]; managed {
+                    continue
+                }
+            }
+            // Options are never anchored inside a managed service block.
+            if !inBlock && (packagePattern.MatchString(line) || importPattern.MatchString(trimmed) || (anchor < 0 && syntaxPattern.MatchString(line))) {
+                anchor = len(kept)
+            }
+        }
+        depth += braceDelta(line)
+        kept = append(kept, line)
+    }
+
+    var block []string
+    for _, name := range sortedKeys(options) {
+        if options[name] != "" {
+            block = append(block, formatFileOption(name, options[name]))
+        }
+    }
+    if len(block) == 0 {
+        return strings.Join(kept, "\n")
+    }
+
+    head := kept[:anchor+1]
+    tail := kept[anchor+1:]
+    for len(tail) > 0 && strings.TrimSpace(tail[0]) == "" {
+        tail = tail[1:]
+    }
+
+    result := make([]string, 0, len(kept)+len(block)+2)
+    result = append(result, head...)
+    if len(head) > 0 {
+        result = append(result, "")
+    }
+    result = append(result, block...)
+    result = append(result, "")
+    result = append(result, tail...)
+    return strings.Join(result, "\n")
+}
+
+// formatFileOption renders a single `option name = value;` statement.
+func formatFileOption(name, value string) string {
+    if !unquotedFileOptions[name] {
+        value = strconv.Quote(value)
+    }
+    return fmt.Sprintf("option %s = %s;", name, value)
+}
+
+// braceDelta returns the change in brace depth caused by line, ignoring
+// braces inside string literals and // comments.
+func braceDelta(line string) int {
+    delta := 0
+    var quote rune
+    prev := rune(0)
+    for _, r := range line {
+        switch {
+        case quote != 0:
+            if r == quote && prev != '\\' {
+                quote = 0
+            }
+        case r == '"' || r == '\'':
+            quote = r
+        case r == '/' && prev == '/':
+            return delta
+        case r == '{':
+            delta++
+        case r == '}':
+            delta--
+        }
+        prev = r
+    }
+    return delta
+}
+
+// applyGlobalFileOptions injects the configured file options into the
+// rendered global proto content.
+func (pm *ProtoManager) applyGlobalFileOptions(content string) (string, error) {
+    data := FileOptionsData{Module: pm.Config.Module, Package: protoPackage(content)}
+    options, err := renderFileOptions(pm.fileOptionTemplates(""), data)
+    if err != nil {
+        return "", err
+    }
+    return injectFileOptions(content, options), nil
+}
+
+// InjectServiceFileOptions injects the configured file options into every
+// proto file of a registered service, returning the files that changed.
+func (pm *ProtoManager) InjectServiceFileOptions(serviceName string, metadata ServiceMetadata) ([]string, error) {
+    templates := pm.fileOptionTemplates(serviceName)
+    if len(templates) == 0 {
+        return nil, nil
+    }
+
+    files, err := pm.DiscoverProtos(serviceName)
+    var none *NoProtoFilesError
+    if errors.As(err, &none) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var changed []string
+    for _, file := range files {
+        data, err := os.ReadFile(file)
+        if err != nil {
+            return changed, err
+        }
+        content := string(data)
+
+        options, err := renderFileOptions(templates, FileOptionsData{
+            Module:  pm.Config.Module,
+            Package: protoPackage(content),
+            Service: serviceName,
+            Domain:  metadata.Domain,
+            Version: metadata.Version,
+        })
+        if err != nil {
+            return changed, fmt.Errorf("service '%s': %w", serviceName, err)
+        }
+
+        if updated := injectFileOptions(content, options); updated != content {
+            if err := writeFileAtomic(file, []byte(updated), 0644); err != nil {
+                return changed, err
+            }
+            changed = append(changed, file)
+        }
+    }
+
+    if len(changed) > 0 {
+        pm.Logger.Infof("Injected file options into %d proto file(s) of service '%s'", len(changed), serviceName)
+        pm.emitEvent(Event{Type: "FileOptionsInjected", Message: fmt.Sprintf("File options injected into %d proto file(s) of service '%s'", len(changed), serviceName)})
+    }
+    return changed, nil
+}
+
+// protoPackage returns the package declared in a proto file, if any.
+func protoPackage(content string) string {
+    for _, line := range strings.Split(content, "\n") {
+        if m := packagePattern.FindStringSubmatch(line); m != nil {
+            return m[1]
+        }
+    }
+    return ""
+}
@@ -0,0 +1,66 @@
+// protomanager/file_options_test.go
+package protomanager
+
+import "testing"
+
+func TestInjectFileOptions(t *testing.T) {
+    const base = "syntax = \"proto3\";\n\npackage pay;\n\nmessage M {}\n"
+    tests := []struct {
+        name    string
+        content string
+        options map[string]string
+        want    string
+    }{
+        {
+            name:    "inserts after package",
+            content: base,
+            options: map[string]string{"go_package": "example.com/pay", "java_multiple_files": "true"},
+            want:    "syntax = \"proto3\";\n\npackage pay;\n\noption go_package = \"example.com/pay\";\noption java_multiple_files = true;\n\nmessage M {}\n",
+        },
+        {
+            name:    "replaces existing value",
+            content: "syntax = \"proto3\";\n\npackage pay;\n\noption go_package = \"old\";\n\nmessage M {}\n",
+            options: map[string]string{"go_package": "new"},
+            want:    "syntax = \"proto3\";\n\npackage pay;\n\noption go_package = \"new\";\n\nmessage M {}\n",
+        },
+        {
+            name:    "removes option rendered empty",
+            content: "syntax = \"proto3\";\n\npackage pay;\n\noption go_package = \"old\";\noption java_package = \"com.pay\";\n\nmessage M {}\n",
+            options: map[string]string{"go_package": ""},
+            want:    "syntax = \"proto3\";\n\npackage pay;\n\noption java_package = \"com.pay\";\n\nmessage M {}\n",
+        },
+        {
+            name:    "leaves nested options alone",
+            content: "syntax = \"proto3\";\n\npackage pay;\n\nmessage M {\n  option deprecated = true;\n}\n",
+            options: map[string]string{"deprecated": ""},
+            want:    "syntax = \"proto3\";\n\npackage pay;\n\nmessage M {\n  option deprecated = true;\n}\n",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := injectFileOptions(tt.content, tt.options)
+            if got != tt.want {
+                t.Fatalf("injectFileOptions() =\n%s\nwant\n%s", got, tt.want)
+            }
+            if again := injectFileOptions(got, tt.options); again != got {
+                t.Errorf("injectFileOptions() is not idempotent:\n%s", again)
+            }
+        })
+    }
+}
+
+func TestRenderFileOptionsKeepsEmptyValues(t *testing.T) {
+    options, err := renderFileOptions(map[string]string{
+        "go_package":   "{{.Module}}/{{.Domain}}",
+        "java_package": "{{if .Service}}com.{{.Service}}{{end}}",
+    }, FileOptionsData{Module: "example.com", Domain: "pay"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if options["go_package"] != "example.com/pay" {
+        t.Errorf("go_package = %q", options["go_package"])
+    }
+    if value, ok := options["java_package"]; !ok || value != "" {
+        t.Errorf("java_package = %q, %v; want an empty value so the option is removed", value, ok)
+    }
+}
@@ -0,0 +1,368 @@
+// protomanager/format.go
+package protomanager
+
+import (
+    "bytes"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strings"
+)
+
+// formatIndent is the indentation used for each nesting level.
+const formatIndent = "  "
+
+var (
+    importPattern     = regexp.MustCompile(`^import\s+(?:(?:public|weak)\s+)?"([^"]*)"`)
+    headerStmtPattern = regexp.MustCompile(`^(syntax|edition|package|import|option)\b`)
+)
+
+// headerStatement is a top-level syntax, package, import or option statement
+// together with the comment lines directly above it.
+type headerStatement struct {
+    Kind  string
+    Key   string
+    Lines []string
+}
+
+// Format reformats proto source canonically: the syntax, package, import and
+// option statements are hoisted to the top in that order (imports sorted by
+// path, options by name) with their leading comments, below any comments
+// detached from the first statement by a blank line, such as a copyright
+// notice. Nesting is indented with two spaces, trailing whitespace and
+// repeated blank lines are removed, and trailing comments are separated from
+// code by a single space.
+// Managed service blocks of the global proto file are left in place.
+func Format(src []byte) ([]byte, error) {
+    lines := strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+
+    preamble, lines := splitPreamble(lines)
+    header, body, err := splitHeader(lines)
+    if err != nil {
+        return nil, err
+    }
+
+    ordered := preamble
+    for _, kind := range []string{"syntax", "edition", "package", "import", "option"} {
+        group := header[kind]
+        sort.SliceStable(group, func(i, j int) bool { return group[i].Key < group[j].Key })
+        if len(group) == 0 {
+            continue
+        }
+        for _, stmt := range group {
+            ordered = append(ordered, stmt.Lines...)
+        }
+        ordered = append(ordered, "")
+    }
+    ordered = append(ordered, body...)
+
+    formatted, err := indentLines(ordered)
+    if err != nil {
+        return nil, err
+    }
+    return []byte(formatted), nil
+}
+
+// splitPreamble splits off the comments at the start of the file that are
+// separated from the first statement by a blank line.
+func splitPreamble(lines []string) ([]string, []string) {
+    var scan protoScanner
+    split := 0
+    for i, line := range lines {
+        trimmed := strings.TrimSpace(line)
+        if !scan.inComment && trimmed != "" && !strings.HasPrefix(trimmed, "//") && !strings.HasPrefix(trimmed, "/*") {
+            break
+        }
+        if !scan.inComment && trimmed == "" {
+            split = i + 1
+        }
+        scan.scan(line)
+    }
+    return lines[:split], lines[split:]
+}
+
+// splitHeader separates top-level header statements from the rest of the file.
+// Managed service block markers are barriers: they stay in place, and
+// statements between a begin and an end marker are never hoisted.
+func splitHeader(lines []string) (map[string][]headerStatement, []string, error) {
+    header := map[string][]headerStatement{}
+    var body, pending []string
+    var scan protoScanner
+    inBlock := false
+
+    for i := 0; i < len(lines); i++ {
+        trimmed := strings.TrimSpace(lines[i])
+        atTop := scan.depth == 0 && !scan.inComment
+
+        switch {
+        case atTop && (strings.HasPrefix(trimmed, serviceBeginMarker) || strings.HasPrefix(trimmed, serviceEndMarker)):
+            inBlock = strings.HasPrefix(trimmed, serviceBeginMarker)
+            body = append(body, pending...)
+            pending = nil
+            body = append(body, lines[i])
+            continue
+        case inBlock:
+        case atTop && strings.HasPrefix(trimmed, "//"):
+            pending = append(pending, lines[i])
+            continue
+        case atTop && headerStmtPattern.MatchString(trimmed):
+            stmt := headerStatement{Kind: headerStmtPattern.FindStringSubmatch(trimmed)[1], Lines: pending}
+            pending = nil
+
+            // A statement may span several lines, e.g. an option with an aggregate value.
+            for ; i < len(lines); i++ {
+                stmt.Lines = append(stmt.Lines, lines[i])
+                scan.scan(lines[i])
+                if scan.depth == 0 && !scan.inComment && strings.HasSuffix(stripLineComment(strings.TrimSpace(lines[i])), ";") {
+                    break
+                }
+            }
+            if i == len(lines) {
+                return nil, nil, fmt.Errorf("unterminated %s statement", stmt.Kind)
+            }
+
+            stmt.Key = headerKey(stmt.Kind, trimmed)
+            header[stmt.Kind] = append(header[stmt.Kind], stmt)
+            continue
+        }
+
+        body = append(body, pending...)
+        pending = nil
+        body = append(body, lines[i])
+        scan.scan(lines[i])
+    }
+    body = append(body, pending...)
+    return header, body, nil
+}
+
+// headerKey returns the sort key of a header statement.
+func headerKey(kind, line string) string {
+    switch kind {
+    case "import":
+        if m := importPattern.FindStringSubmatch(line); m != nil {
+            return m[1]
+        }
+    case "option":
+        if m := optionPattern.FindStringSubmatch(line); m != nil {
+            return m[1]
+        }
+    }
+    return line
+}
+
+// indentLines re-indents lines by nesting depth and normalizes blank lines,
+// trailing whitespace and trailing comments.
+func indentLines(lines []string) (string, error) {
+    var out []string
+    var scan protoScanner
+
+    for _, line := range lines {
+        inComment := scan.inComment
+        trimmed := strings.TrimSpace(line)
+
+        if trimmed == "" {
+            if len(out) > 0 && out[len(out)-1] != "" && !strings.HasSuffix(out[len(out)-1], "{") {
+                out = append(out, "")
+            }
+            continue
+        }
+
+        depth := scan.depth
+        if inComment {
+            // Continuation of a block comment: keep its text, align leading '*'.
+            if strings.HasPrefix(trimmed, "*") {
+                trimmed = " " + trimmed
+            }
+        } else {
+            depth -= leadingClosers(trimmed)
+            trimmed = normalizeTrailingComment(trimmed)
+            if len(out) > 0 && out[len(out)-1] == "" && isCloser(trimmed) {
+                out = out[:len(out)-1]
+            }
+        }
+        if depth < 0 {
+            return "", fmt.Errorf("unbalanced closing brace: %q", trimmed)
+        }
+
+        out = append(out, strings.Repeat(formatIndent, depth)+trimmed)
+        scan.scan(line)
+    }
+
+    if scan.inComment {
+        return "", fmt.Errorf("unterminated block comment")
+    }
+    if scan.depth != 0 {
+        return "", fmt.Errorf("unbalanced braces: %d left open", scan.depth)
+    }
+
+    for len(out) > 0 && out[len(out)-1] == "" {
+        out = out[:len(out)-1]
+    }
+    if len(out) == 0 {
+        return "", nil
+    }
+    return strings.Join(out, "\n") + "\n", nil
+}
+
+// protoScanner tracks nesting depth and block comment state across lines.
+type protoScanner struct {
+    depth     int
+    inComment bool
+}
+
+// scan advances the scanner over one line of proto source.
+func (s *protoScanner) scan(line string) {
+    var quote byte
+    for i := 0; i < len(line); i++ {
+        c := line[i]
+        switch {
+        case s.inComment:
+            if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+                s.inComment = false
+                i++
+            }
+        case quote != 0:
+            if c == '\\' {
+                i++
+            } else if c == quote {
+                quote = 0
+            }
+        case c == '"' || c == '\'':
+            quote = c
+        case c == '/' && i+1 < len(line) && line[i+1] == '/':
+            return
+        case c == '/' && i+1 < len(line) && line[i+1] == '*':
+            s.inComment = true
+            i++
+        case c == '{' || c == '[' || c == '(':
+            s.depth++
+        case c == '}' || c == ']' || c == ')':
+            s.depth--
+        }
+    }
+}
+
+// leadingClosers counts the closing brackets a line starts with.
+func leadingClosers(line string) int {
+    n := 0
+    for _, c := range line {
+        switch c {
+        case '}', ']', ')':
+            n++
+        case ' ', '\t':
+        default:
+            return n
+        }
+    }
+    return n
+}
+
+// isCloser reports whether line starts with a closing bracket.
+func isCloser(line string) bool {
+    return leadingClosers(line) > 0
+}
+
+// normalizeTrailingComment separates a trailing // comment from code by a
+// single space and ensures a space after the comment marker.
+func normalizeTrailingComment(line string) string {
+    idx := lineCommentIndex(line)
+    if idx < 0 {
+        return line
+    }
+    code := strings.TrimRight(line[:idx], " \t")
+    comment := line[idx+2:]
+    if comment != "" && comment[0] != ' ' && comment[0] != '/' && comment[0] != '\t' {
+        comment = " " + comment
+    }
+    if code == "" {
+        return "//" + comment
+    }
+    return code + " //" + comment
+}
+
+// stripLineComment returns line without its trailing // comment.
+func stripLineComment(line string) string {
+    if idx := lineCommentIndex(line); idx >= 0 {
+        return strings.TrimSpace(line[:idx])
+    }
+    return line
+}
+
+// lineCommentIndex returns the index of the // starting a comment in line, or -1.
+func lineCommentIndex(line string) int {
+    var quote byte
+    for i := 0; i < len(line); i++ {
+        c := line[i]
+        switch {
+        case quote != 0:
+            if c == '\\' {
+                i++
+            } else if c == quote {
+                quote = 0
+            }
+        case c == '"' || c == '\'':
+            quote = c
+        case c == '/' && i+1 < len(line) && line[i+1] == '/':
+            return i
+        case c == '/' && i+1 < len(line) && line[i+1] == '*':
+            return -1
+        }
+    }
+    return -1
+}
+
+// FormatFile formats the proto file at path in place. With check set, the
+// file is left untouched. It reports whether the file was not already formatted.
+func FormatFile(path string, check bool) (bool, error) {
+    src, err := os.ReadFile(path)
+    if err != nil {
+        return false, err
+    }
+    formatted, err := Format(src)
+    if err != nil {
+        return false, fmt.Errorf("%s: %w", path, err)
+    }
+    if bytes.Equal(src, formatted) {
+        return false, nil
+    }
+    if check {
+        return true, nil
+    }
+
+    info, err := os.Stat(path)
+    if err != nil {
+        return true, err
+    }
+    return true, writeFileAtomic(path, formatted, info.Mode().Perm())
+}
+
+// FormatPaths formats every .proto file named by paths, walking directories
+// recursively, and returns the files that were (or, with check, would be) changed.
+func FormatPaths(paths []string, check bool) ([]string, error) {
+    var changed []string
+    for _, root := range paths {
+        err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+            if err != nil {
+                return err
+            }
+            if d.IsDir() || (path != root && filepath.Ext(path) != ".proto") {
+                return nil
+            }
+            didChange, err := FormatFile(path, check)
+            if err != nil {
+                return err
+            }
+            if didChange {
+                changed = append(changed, path)
+            }
+            return nil
+        })
+        if err != nil {
+            return changed, err
+        }
+    }
+    return changed, nil
+}
@@ -0,0 +1,121 @@
+// protomanager/format_test.go
+package protomanager
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestFormat(t *testing.T) {
+    tests := []struct {
+        name string
+        src  string
+        want string
+    }{
+        {
+            name: "header hoisted and sorted",
+            src:  "message A {}\nimport \"b.proto\";\n// a comment\nimport \"a.proto\";\noption go_package = \"x\";\npackage p;\nsyntax = \"proto3\";\n",
+            want: "syntax = \"proto3\";\n\npackage p;\n\n// a comment\nimport \"a.proto\";\nimport \"b.proto\";\n\noption go_package = \"x\";\n\nmessage A {}\n",
+        },
+        {
+            name: "indentation and blank lines",
+            src:  "message A {\n\n\tstring a = 1;   //note\n\n\n  message B {\nint32 b = 1;\n\n}\n}\n\n\n",
+            want: "message A {\n  string a = 1; // note\n\n  message B {\n    int32 b = 1;\n  }\n}\n",
+        },
+        {
+            name: "multi-line option",
+            src:  "option (ext) = {\na: 1\n};\nsyntax = \"proto3\";\n",
+            want: "syntax = \"proto3\";\n\noption (ext) = {\n  a: 1\n};\n",
+        },
+        {
+            name: "block comment kept",
+            src:  "/*\n* doc\n*/\nmessage A {}\n",
+            want: "/*\n * doc\n */\nmessage A {}\n",
+        },
+        {
+            name: "detached comments stay on top",
+            src:  "// Copyright 2024 Example\n// All rights reserved.\n\n/*\n * License.\n */\n\n// Package p.\npackage p;\nsyntax = \"proto3\";\n",
+            want: "// Copyright 2024 Example\n// All rights reserved.\n\n/*\n * License.\n */\n\nsyntax = \"proto3\";\n\n// Package p.\npackage p;\n",
+        },
+        {
+            name: "managed blocks are barriers",
+            src: "syntax = \"proto3\";\n\n" +
+                serviceBeginMarker + "a\nimport \"z.proto\";\nmessage A {}\n" + serviceEndMarker + "a\n" +
+                "import \"top.proto\";\n" +
+                serviceBeginMarker + "b\n// b's import\nimport \"y.proto\";\n" + serviceEndMarker + "b\n",
+            want: "syntax = \"proto3\";\n\nimport \"top.proto\";\n\n" +
+                serviceBeginMarker + "a\nimport \"z.proto\";\nmessage A {}\n" + serviceEndMarker + "a\n" +
+                serviceBeginMarker + "b\n// b's import\nimport \"y.proto\";\n" + serviceEndMarker + "b\n",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := Format([]byte(tt.src))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if string(got) != tt.want {
+                t.Errorf("Format() =\n%s\nwant\n%s", got, tt.want)
+            }
+            again, err := Format(got)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if string(again) != string(got) {
+                t.Errorf("Format is not idempotent:\n%s", again)
+            }
+        })
+    }
+}
+
+func TestFormatErrors(t *testing.T) {
+    for _, src := range []string{
+        "message A {\n",
+        "}\n",
+        "/* open\n",
+        "option go_package = \"x\"\n",
+    } {
+        if _, err := Format([]byte(src)); err == nil {
+            t.Errorf("Format(%q) succeeded, want an error", src)
+        }
+    }
+}
+
+// TestGlobalProtoRoundTrip checks that parsing a rendered and formatted global
+// proto file yields the blocks it was rendered from.
+func TestGlobalProtoRoundTrip(t *testing.T) {
+    pm := newTestProtoManager(t)
+    pm.Config.GlobalFileOptions = map[string]string{"go_package": "example.com/gen;gen"}
+
+    gp := &globalProto{Base: defaultGlobalProtoBase, Services: map[string]string{
+        "a": normalizeDefinition("import \"google/protobuf/timestamp.proto\";\n\nmessage A {\n  google.protobuf.Timestamp at = 1;\n}\n"),
+        "b": normalizeDefinition("// Durations.\nimport \"google/protobuf/duration.proto\";\noption java_multiple_files = true;\n\nmessage B {\n  google.protobuf.Duration d = 1;\n}\n"),
+    }}
+
+    content, err := pm.renderGlobalProto(gp)
+    if err != nil {
+        t.Fatal(err)
+    }
+    formatted, err := Format([]byte(content))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if string(formatted) != content {
+        t.Errorf("rendered global proto is not formatted:\n%s", content)
+    }
+
+    parsed, err := parseGlobalProto(string(formatted))
+    if err != nil {
+        t.Fatalf("formatted global proto does not parse: %v\n%s", err, formatted)
+    }
+    if !reflect.DeepEqual(parsed.Services, gp.Services) {
+        t.Errorf("blocks changed in the round trip:\ngot  %q\nwant %q", parsed.Services, gp.Services)
+    }
+    if got := protoPackage(parsed.Base); got != "protomanager" {
+        t.Errorf("base lost its package: %q", parsed.Base)
+    }
+    if !strings.Contains(parsed.Base, `option go_package = "example.com/gen;gen";`) {
+        t.Errorf("go_package did not land in the base:\n%s", parsed.Base)
+    }
+}
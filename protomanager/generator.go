@@ -0,0 +1,395 @@
+// protomanager/generator.go
+package protomanager
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/bufbuild/protocompile"
+    "github.com/bufbuild/protocompile/reporter"
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/reflect/protodesc"
+    "google.golang.org/protobuf/reflect/protoreflect"
+    "google.golang.org/protobuf/types/descriptorpb"
+    "google.golang.org/protobuf/types/pluginpb"
+)
+
+// builtinProtocPlugins are code generators compiled into protoc itself rather
+// than shipped as protoc-gen-* binaries.
+var builtinProtocPlugins = map[string]bool{
+    "cpp":    true,
+    "csharp": true,
+    "java":   true,
+    "kotlin": true,
+    "objc":   true,
+    "php":    true,
+    "pyi":    true,
+    "python": true,
+    "ruby":   true,
+    "rust":   true,
+}
+
+// PluginInvocation describes one run of a code generator plugin.
+type PluginInvocation struct {
+    Name    string   // Plugin name; selects --<name>_out and protoc-gen-<name>
+    Binary  string   // Plugin executable; empty looks up protoc-gen-<name> on PATH
+    Options []string // Plugin parameters, passed comma-separated
+    OutDir  string   // Directory the plugin writes to
+}
+
+// GenerateRequest describes a single generation run.
+type GenerateRequest struct {
+    IncludePaths []string           // Ordered include paths, like protoc's --proto_path
+    Files        []string           // Proto files on disk to generate code for
+    Plugins      []PluginInvocation // Plugins to run over Files
+
+    DescriptorSetOut  string // Optional path of a FileDescriptorSet to write
+    IncludeImports    bool   // Include all dependencies in the descriptor set
+    IncludeSourceInfo bool   // Keep source code info in the descriptor set
+}
+
+// Generator compiles proto files and runs code generator plugins.
+type Generator interface {
+    Generate(ctx context.Context, req GenerateRequest) error
+}
+
+// CodeGenerator returns the Generator used by the ProtoManager: the Generator
+// field when set, otherwise the backend selected by Config.Generator, running
+// the pinned toolchain when one is configured.
+func (pm *ProtoManager) CodeGenerator() Generator {
+    if pm.Generator != nil {
+        return pm.Generator
+    }
+
+    timeouts := pm.Config.Timeouts
+    var backend Generator = &ProtocGenerator{Binary: pm.protocBinary(), Timeout: timeouts.Protoc}
+    if pm.Config.Generator == "native" {
+        backend = &NativeGenerator{PluginTimeout: timeouts.Plugin}
+    }
+    if pm.Config.Toolchain.locked() {
+        return &lockedGenerator{pm: pm, backend: backend}
+    }
+    return backend
+}
+
+// Generate runs req through CodeGenerator. When the run fails with
+// diagnostics they are emitted as a GenerationDiagnostics event whose
+// payload is the []Diagnostic.
+func (pm *ProtoManager) Generate(ctx context.Context, req GenerateRequest) error {
+    err := pm.CodeGenerator().Generate(ctx, req)
+    if diagnostics := Diagnostics(err); len(diagnostics) > 0 {
+        pm.emitEvent(Event{Type: "GenerationDiagnostics", Message: err.Error(), Payload: diagnostics})
+    }
+    return err
+}
+
+// ProtocGenerator generates code by executing the protoc binary.
+type ProtocGenerator struct {
+    Binary  string        // protoc executable; empty uses "protoc" from PATH
+    Timeout time.Duration // Kills protoc and its plugins after this long; zero waits for ctx
+}
+
+// Generate implements Generator.
+func (g *ProtocGenerator) Generate(ctx context.Context, req GenerateRequest) error {
+    binary := g.Binary
+    if binary == "" {
+        binary = "protoc"
+    }
+
+    var args []string
+    for _, plugin := range req.Plugins {
+        if plugin.Binary != "" {
+            args = append(args, fmt.Sprintf("--plugin=protoc-gen-%s=%s", plugin.Name, plugin.Binary))
+        }
+        args = append(args, fmt.Sprintf("--%s_out=%s", plugin.Name, plugin.OutDir))
+        if len(plugin.Options) > 0 {
+            args = append(args, fmt.Sprintf("--%s_opt=%s", plugin.Name, strings.Join(plugin.Options, ",")))
+        }
+    }
+    if req.DescriptorSetOut != "" {
+        args = append(args, "--descriptor_set_out="+req.DescriptorSetOut)
+        if req.IncludeImports {
+            args = append(args, "--include_imports")
+        }
+        if req.IncludeSourceInfo {
+            args = append(args, "--include_source_info")
+        }
+    }
+    args = append(args, protocPathArgs(req.IncludePaths)...)
+    args = append(args, req.Files...)
+
+    cmd := NewCommand(ctx, g.Timeout, binary, args...)
+    output, err := cmd.CombinedOutput()
+    if err != nil {
+        return newGenerateError(filepath.Base(binary), err, string(output), req.IncludePaths)
+    }
+    return nil
+}
+
+// NativeGenerator parses and links protos in-process with a pure-Go compiler
+// and invokes plugins over the CodeGeneratorRequest/CodeGeneratorResponse
+// protocol, so protoc does not need to be installed. Well-known types are
+// provided by the compiler. Plugins built into protoc are not available.
+type NativeGenerator struct {
+    PluginTimeout time.Duration // Kills each plugin after this long; zero waits for ctx
+}
+
+// Generate implements Generator.
+func (g *NativeGenerator) Generate(ctx context.Context, req GenerateRequest) error {
+    names, compiled, err := compileProtos(ctx, req.IncludePaths, req.Files)
+    if err != nil {
+        return err
+    }
+
+    // All files in dependency order, as plugins and descriptor sets expect.
+    var all []*descriptorpb.FileDescriptorProto
+    seen := map[string]bool{}
+    var visit func(fd protoreflect.FileDescriptor)
+    visit = func(fd protoreflect.FileDescriptor) {
+        if seen[fd.Path()] {
+            return
+        }
+        seen[fd.Path()] = true
+        imports := fd.Imports()
+        for i := 0; i < imports.Len(); i++ {
+            visit(imports.Get(i).FileDescriptor)
+        }
+        all = append(all, protodesc.ToFileDescriptorProto(fd))
+    }
+    for _, file := range compiled {
+        visit(file)
+    }
+
+    if req.DescriptorSetOut != "" {
+        if err := writeNativeDescriptorSet(req, names, all); err != nil {
+            return err
+        }
+    }
+
+    for _, plugin := range req.Plugins {
+        if err := runPlugin(ctx, g.PluginTimeout, plugin, req.IncludePaths, names, all); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// compileProtos parses and links files in-process, returning their import
+// names and descriptors. Every located compiler error is collected into a
+// *GenerateError instead of stopping at the first one.
+func compileProtos(ctx context.Context, includePaths, files []string) ([]string, []protoreflect.FileDescriptor, error) {
+    resolver := &ImportResolver{IncludePaths: includePaths}
+    names := make([]string, 0, len(files))
+    for _, file := range files {
+        name, err := resolver.NameOf(file)
+        if err != nil {
+            return nil, nil, err
+        }
+        names = append(names, name)
+    }
+
+    var diagnostics []Diagnostic
+    report := func(severity string) func(reporter.ErrorWithPos) {
+        return func(err reporter.ErrorWithPos) {
+            pos := err.GetPosition()
+            diagnostics = append(diagnostics, Diagnostic{
+                Source:   "protocompile",
+                File:     pos.Filename,
+                Line:     pos.Line,
+                Column:   pos.Col,
+                Severity: severity,
+                Message:  err.Unwrap().Error(),
+            })
+        }
+    }
+    compiler := protocompile.Compiler{
+        Resolver:       protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: includePaths}),
+        SourceInfoMode: protocompile.SourceInfoStandard,
+        Reporter: reporter.NewReporter(func(err reporter.ErrorWithPos) error {
+            report("error")(err)
+            return nil
+        }, report("warning")),
+    }
+    compiled, err := compiler.Compile(ctx, names...)
+    if err != nil {
+        return nil, nil, &GenerateError{Tool: "protocompile", Diagnostics: locateDiagnostics(diagnostics, includePaths), Err: err}
+    }
+
+    descriptors := make([]protoreflect.FileDescriptor, 0, len(compiled))
+    for _, file := range compiled {
+        descriptors = append(descriptors, file)
+    }
+    return names, descriptors, nil
+}
+
+// writeNativeDescriptorSet writes the FileDescriptorSet requested by req.
+func writeNativeDescriptorSet(req GenerateRequest, names []string, all []*descriptorpb.FileDescriptorProto) error {
+    requested := map[string]bool{}
+    for _, name := range names {
+        requested[name] = true
+    }
+
+    set := &descriptorpb.FileDescriptorSet{}
+    for _, fd := range all {
+        if !req.IncludeImports && !requested[fd.GetName()] {
+            continue
+        }
+        if !req.IncludeSourceInfo {
+            fd = proto.Clone(fd).(*descriptorpb.FileDescriptorProto)
+            fd.SourceCodeInfo = nil
+        }
+        set.File = append(set.File, fd)
+    }
+
+    data, err := proto.Marshal(set)
+    if err != nil {
+        return fmt.Errorf("failed to encode descriptor set: %w", err)
+    }
+    if err := os.MkdirAll(filepath.Dir(req.DescriptorSetOut), os.ModePerm); err != nil {
+        return err
+    }
+    return os.WriteFile(req.DescriptorSetOut, data, 0644)
+}
+
+// runPlugin executes a protoc plugin over the CodeGeneratorRequest protocol
+// and writes the files it returns. includePaths locate the files named in
+// the plugin's diagnostics.
+func runPlugin(ctx context.Context, timeout time.Duration, plugin PluginInvocation, includePaths []string, names []string, all []*descriptorpb.FileDescriptorProto) error {
+    if builtinProtocPlugins[plugin.Name] && plugin.Binary == "" {
+        return fmt.Errorf("plugin '%s' is built into protoc and is not available with the native generator", plugin.Name)
+    }
+
+    binary := plugin.Binary
+    if binary == "" {
+        binary = "protoc-gen-" + plugin.Name
+    }
+    path, err := exec.LookPath(binary)
+    if err != nil {
+        return fmt.Errorf("plugin '%s' not found: %w", plugin.Name, err)
+    }
+
+    request := &pluginpb.CodeGeneratorRequest{
+        FileToGenerate: names,
+        ProtoFile:      all,
+    }
+    if len(plugin.Options) > 0 {
+        request.Parameter = proto.String(strings.Join(plugin.Options, ","))
+    }
+    input, err := proto.Marshal(request)
+    if err != nil {
+        return fmt.Errorf("failed to encode request for plugin '%s': %w", plugin.Name, err)
+    }
+
+    var stdout, stderr bytes.Buffer
+    cmd := NewCommand(ctx, timeout, path)
+    cmd.Stdin = bytes.NewReader(input)
+    cmd.Stdout = &stdout
+    cmd.Stderr = &stderr
+    if err := cmd.Run(); err != nil {
+        return newGenerateError(filepath.Base(path), err, stderr.String(), includePaths)
+    }
+
+    response := &pluginpb.CodeGeneratorResponse{}
+    if err := proto.Unmarshal(stdout.Bytes(), response); err != nil {
+        return fmt.Errorf("failed to decode response from plugin '%s': %w", plugin.Name, err)
+    }
+    if response.Error != nil {
+        return newGenerateError(filepath.Base(path), errors.New("plugin reported an error"), response.GetError(), includePaths)
+    }
+
+    if err := checkPluginFeatures(plugin.Name, response, names, all); err != nil {
+        return err
+    }
+
+    // Like protoc, every file name is checked before anything is written. A
+    // file without a name continues the previous one.
+    var outputs []string
+    contents := map[string]*strings.Builder{}
+    for _, file := range response.File {
+        if file.GetInsertionPoint() != "" {
+            return fmt.Errorf("plugin '%s': insertion points are not supported by the native generator", plugin.Name)
+        }
+        if file.GetName() == "" {
+            if len(outputs) == 0 {
+                return fmt.Errorf("plugin '%s': first file returned has no name", plugin.Name)
+            }
+            contents[outputs[len(outputs)-1]].WriteString(file.GetContent())
+            continue
+        }
+        out, err := pluginOutputPath(plugin.OutDir, file.GetName())
+        if err != nil {
+            return fmt.Errorf("plugin '%s': %w", plugin.Name, err)
+        }
+        if contents[out] == nil {
+            contents[out] = &strings.Builder{}
+        } else {
+            contents[out].Reset()
+        }
+        outputs = append(outputs, out)
+        contents[out].WriteString(file.GetContent())
+    }
+    for out, content := range contents {
+        if err := os.MkdirAll(filepath.Dir(out), os.ModePerm); err != nil {
+            return err
+        }
+        if err := os.WriteFile(out, []byte(content.String()), 0644); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// checkPluginFeatures fails, as protoc does, when a file to generate uses
+// proto3 optional fields but the plugin did not declare support for them in
+// its response's supported_features.
+func checkPluginFeatures(plugin string, response *pluginpb.CodeGeneratorResponse, names []string, all []*descriptorpb.FileDescriptorProto) error {
+    if response.GetSupportedFeatures()&uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL) != 0 {
+        return nil
+    }
+    requested := map[string]bool{}
+    for _, name := range names {
+        requested[name] = true
+    }
+    for _, fd := range all {
+        if requested[fd.GetName()] && hasProto3Optional(fd.GetMessageType()) {
+            return fmt.Errorf("plugin '%s' does not support proto3 optional fields, which '%s' uses", plugin, fd.GetName())
+        }
+    }
+    return nil
+}
+
+// hasProto3Optional reports whether any of messages, or the messages nested in
+// them, declares a proto3 optional field.
+func hasProto3Optional(messages []*descriptorpb.DescriptorProto) bool {
+    for _, message := range messages {
+        for _, field := range message.GetField() {
+            if field.GetProto3Optional() {
+                return true
+            }
+        }
+        if hasProto3Optional(message.GetNestedType()) {
+            return true
+        }
+    }
+    return false
+}
+
+// pluginOutputPath resolves a file name returned by a plugin against outDir,
+// rejecting names that are empty, absolute or escape outDir.
+func pluginOutputPath(outDir, name string) (string, error) {
+    rel := filepath.Clean(filepath.FromSlash(name))
+    if name == "" || filepath.IsAbs(rel) || strings.HasPrefix(name, "/") || filepath.VolumeName(rel) != "" {
+        return "", fmt.Errorf("invalid output file name %q: must be a relative path", name)
+    }
+    if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || rel == "." {
+        return "", fmt.Errorf("invalid output file name %q: must not escape the output directory", name)
+    }
+    return filepath.Join(outDir, rel), nil
+}
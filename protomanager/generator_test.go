@@ -0,0 +1,213 @@
+// protomanager/generator_test.go
+package protomanager
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/types/descriptorpb"
+    "google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestPluginOutputPath(t *testing.T) {
+    out := filepath.Join("gen", "go")
+    tests := []struct {
+        name string
+        want string // empty when the name must be rejected
+    }{
+        {"a.pb.go", filepath.Join(out, "a.pb.go")},
+        {"pkg/v1/a.pb.go", filepath.Join(out, "pkg", "v1", "a.pb.go")},
+        {"pkg/../a.pb.go", filepath.Join(out, "a.pb.go")},
+        {"./a.pb.go", filepath.Join(out, "a.pb.go")},
+        {"..foo/a.pb.go", filepath.Join(out, "..foo", "a.pb.go")},
+        {"", ""},
+        {".", ""},
+        {"..", ""},
+        {"../a.pb.go", ""},
+        {"pkg/../../a.pb.go", ""},
+        {"/etc/passwd", ""},
+    }
+    for _, tt := range tests {
+        got, err := pluginOutputPath(out, tt.name)
+        if tt.want == "" {
+            if err == nil {
+                t.Errorf("pluginOutputPath(%q) = %q, want an error", tt.name, got)
+            }
+            continue
+        }
+        if err != nil || got != tt.want {
+            t.Errorf("pluginOutputPath(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+        }
+    }
+}
+
+// TestNativeGenerateWellKnownTypes generates a descriptor set for a file
+// importing a well-known type with an empty vendor cache and no protoc.
+func TestNativeGenerateWellKnownTypes(t *testing.T) {
+    dir := t.TempDir()
+    protoDir := filepath.Join(dir, "proto")
+    cache := filepath.Join(dir, "vendor")
+    for _, d := range []string{protoDir, cache} {
+        if err := os.MkdirAll(d, os.ModePerm); err != nil {
+            t.Fatal(err)
+        }
+    }
+    file := filepath.Join(protoDir, "event.proto")
+    src := "syntax = \"proto3\";\npackage a;\nimport \"google/protobuf/timestamp.proto\";\nmessage Event { google.protobuf.Timestamp at = 1; }\n"
+    if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("PATH", dir)
+
+    includePaths := []string{protoDir, cache}
+    resolved, err := (&ImportResolver{IncludePaths: includePaths}).Resolve([]string{file})
+    if err != nil {
+        t.Fatalf("Resolve: %v", err)
+    }
+    if len(resolved) != 1 || resolved[0].Name != "event.proto" {
+        t.Errorf("Resolve = %v, want only event.proto", resolved)
+    }
+
+    out := filepath.Join(dir, "event.binpb")
+    req := GenerateRequest{IncludePaths: includePaths, Files: []string{file}, DescriptorSetOut: out, IncludeImports: true}
+    if err := (&NativeGenerator{}).Generate(context.Background(), req); err != nil {
+        t.Fatalf("Generate: %v", err)
+    }
+    set := readDescriptorSet(t, out)
+    var names []string
+    for _, fd := range set.File {
+        names = append(names, fd.GetName())
+    }
+    if want := []string{"google/protobuf/timestamp.proto", "event.proto"}; !reflect.DeepEqual(names, want) {
+        t.Errorf("descriptor set files = %v, want %v", names, want)
+    }
+}
+
+// readDescriptorSet decodes the FileDescriptorSet at path.
+func readDescriptorSet(t *testing.T, path string) *descriptorpb.FileDescriptorSet {
+    t.Helper()
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    set := &descriptorpb.FileDescriptorSet{}
+    if err := proto.Unmarshal(data, set); err != nil {
+        t.Fatal(err)
+    }
+    return set
+}
+
+// fakePlugin writes an executable that ignores its request and replies with response.
+func fakePlugin(t *testing.T, response *pluginpb.CodeGeneratorResponse) string {
+    t.Helper()
+    dir := t.TempDir()
+    data, err := proto.Marshal(response)
+    if err != nil {
+        t.Fatal(err)
+    }
+    reply := filepath.Join(dir, "response.binpb")
+    if err := os.WriteFile(reply, data, 0644); err != nil {
+        t.Fatal(err)
+    }
+    binary := filepath.Join(dir, "protoc-gen-fake")
+    script := fmt.Sprintf("#!/bin/sh\ncat >/dev/null\ncat '%s'\n", reply)
+    if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+        t.Fatal(err)
+    }
+    return binary
+}
+
+func TestRunPluginResponse(t *testing.T) {
+    proto3Optional := uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+    plain := &descriptorpb.FileDescriptorProto{
+        Name:        proto.String("a.proto"),
+        MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("A")}},
+    }
+    optional := &descriptorpb.FileDescriptorProto{
+        Name: proto.String("a.proto"),
+        MessageType: []*descriptorpb.DescriptorProto{{
+            Name: proto.String("A"),
+            NestedType: []*descriptorpb.DescriptorProto{{
+                Name:  proto.String("B"),
+                Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("b"), Proto3Optional: proto.Bool(true)}},
+            }},
+        }},
+    }
+    file := func(name, content string) *pluginpb.CodeGeneratorResponse_File {
+        f := &pluginpb.CodeGeneratorResponse_File{Content: proto.String(content)}
+        if name != "" {
+            f.Name = proto.String(name)
+        }
+        return f
+    }
+
+    tests := []struct {
+        name     string
+        file     *descriptorpb.FileDescriptorProto
+        response *pluginpb.CodeGeneratorResponse
+        want     map[string]string
+        wantErr  string
+    }{
+        {
+            name:     "files",
+            file:     plain,
+            response: &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{file("a.txt", "a"), file("b/b.txt", "b")}},
+            want:     map[string]string{"a.txt": "a", "b/b.txt": "b"},
+        },
+        {
+            name:     "unnamed files append to the previous one",
+            file:     plain,
+            response: &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{file("a.txt", "a"), file("", "b"), file("", "c"), file("d.txt", "d")}},
+            want:     map[string]string{"a.txt": "abc", "d.txt": "d"},
+        },
+        {
+            name:     "unnamed first file",
+            file:     plain,
+            response: &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{file("", "a")}},
+            wantErr:  "first file returned has no name",
+        },
+        {
+            name:     "proto3 optional supported",
+            file:     optional,
+            response: &pluginpb.CodeGeneratorResponse{SupportedFeatures: &proto3Optional, File: []*pluginpb.CodeGeneratorResponse_File{file("a.txt", "a")}},
+            want:     map[string]string{"a.txt": "a"},
+        },
+        {
+            name:     "proto3 optional unsupported",
+            file:     optional,
+            response: &pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{file("a.txt", "a")}},
+            wantErr:  "plugin 'fake' does not support proto3 optional fields, which 'a.proto' uses",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            out := t.TempDir()
+            plugin := PluginInvocation{Name: "fake", Binary: fakePlugin(t, tt.response), OutDir: out}
+            err := runPlugin(context.Background(), 0, plugin, nil, []string{"a.proto"}, []*descriptorpb.FileDescriptorProto{tt.file})
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("runPlugin error = %v, want %q", err, tt.wantErr)
+                }
+                if entries, _ := os.ReadDir(out); len(entries) != 0 {
+                    t.Errorf("runPlugin wrote %d file(s) despite failing", len(entries))
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            for name, want := range tt.want {
+                got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(name)))
+                if err != nil || string(got) != want {
+                    t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,184 @@
+// protomanager/global_proto.go
+package protomanager
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+const (
+    // serviceBeginMarker and serviceEndMarker delimit the block owned by a
+    // registered service inside the global proto file.
+    serviceBeginMarker = "// protomanager:begin "
+    serviceEndMarker   = "// protomanager:end "
+
+    // serviceDefinitionFile is where a service's definition is stored under
+    // MicroserviceProtoDir/<service>/ so it can be rebuilt into the global proto.
+    serviceDefinitionFile = "service_definition.proto"
+
+    // defaultGlobalProtoBase is used when the global proto file does not exist yet.
+    defaultGlobalProtoBase = "syntax = \"proto3\";\n\npackage protomanager;\n"
+)
+
+// globalProto is the parsed form of the global proto file. Base holds every
+// line that is not part of a managed service block; Services maps a service
+// name to the definition between its markers.
+type globalProto struct {
+    Base     string
+    Services map[string]string
+}
+
+// parseGlobalProto splits the global proto content into its base and managed blocks.
+func parseGlobalProto(content string) (*globalProto, error) {
+    gp := &globalProto{Services: make(map[string]string)}
+
+    var base, block []string
+    current := ""
+    for i, line := range strings.Split(content, "\n") {
+        trimmed := strings.TrimSpace(line)
+        switch {
+        case strings.HasPrefix(trimmed, serviceBeginMarker):
+            if current != "" {
+                return nil, fmt.Errorf("line %d: block for '%s' opened before '%s' was closed", i+1, strings.TrimPrefix(trimmed, serviceBeginMarker), current)
+            }
+            current = strings.TrimSpace(strings.TrimPrefix(trimmed, serviceBeginMarker))
+            if _, exists := gp.Services[current]; exists {
+                return nil, fmt.Errorf("line %d: duplicate block for service '%s'", i+1, current)
+            }
+            block = nil
+        case strings.HasPrefix(trimmed, serviceEndMarker):
+            name := strings.TrimSpace(strings.TrimPrefix(trimmed, serviceEndMarker))
+            if name != current {
+                return nil, fmt.Errorf("line %d: unexpected end of block '%s'", i+1, name)
+            }
+            gp.Services[current] = normalizeDefinition(strings.Join(block, "\n"))
+            current = ""
+        case current != "":
+            block = append(block, line)
+        default:
+            base = append(base, line)
+        }
+    }
+    if current != "" {
+        return nil, fmt.Errorf("block for service '%s' is never closed", current)
+    }
+
+    gp.Base = strings.TrimRight(strings.Join(base, "\n"), "\n \t") + "\n"
+    return gp, nil
+}
+
+// render produces the global proto content with service blocks in sorted order.
+func (gp *globalProto) render() string {
+    var sb strings.Builder
+    sb.WriteString(gp.Base)
+    for _, name := range sortedKeys(gp.Services) {
+        sb.WriteString("\n")
+        sb.WriteString(serviceBeginMarker + name + "\n")
+        sb.WriteString(gp.Services[name])
+        sb.WriteString(serviceEndMarker + name + "\n")
+    }
+    return sb.String()
+}
+
+// renderGlobalProto renders gp, injects the configured global file options
+// and formats the result canonically.
+func (pm *ProtoManager) renderGlobalProto(gp *globalProto) (string, error) {
+    content, err := pm.applyGlobalFileOptions(gp.render())
+    if err != nil {
+        return "", err
+    }
+    formatted, err := Format([]byte(content))
+    if err != nil {
+        return "", fmt.Errorf("failed to format global proto file: %w", err)
+    }
+    return string(formatted), nil
+}
+
+// normalizeDefinition formats a service definition canonically, trims
+// surrounding blank lines and ensures a trailing newline. Definitions that
+// cannot be formatted are only trimmed.
+func normalizeDefinition(definition string) string {
+    if formatted, err := Format([]byte(definition)); err == nil {
+        definition = string(formatted)
+    }
+    return strings.Trim(definition, "\n") + "\n"
+}
+
+// serviceDefinitionPath returns where the stored definition of serviceName lives.
+func (pm *ProtoManager) serviceDefinitionPath(serviceName string) string {
+    return filepath.Join(pm.MicroserviceProtoDir, serviceName, serviceDefinitionFile)
+}
+
+// serviceDefinition returns the stored definition for serviceName, falling back
+// to the scaffold when none has been stored yet.
+func (pm *ProtoManager) serviceDefinition(serviceName string, metadata ServiceMetadata) (string, error) {
+    data, err := os.ReadFile(pm.serviceDefinitionPath(serviceName))
+    if os.IsNotExist(err) {
+        return pm.renderServiceDefinition(serviceName, metadata)
+    }
+    if err != nil {
+        return "", fmt.Errorf("failed to read stored definition for '%s': %w", serviceName, err)
+    }
+    return normalizeDefinition(string(data)), nil
+}
+
+// storeServiceDefinition persists the definition of serviceName under MicroserviceProtoDir.
+func (pm *ProtoManager) storeServiceDefinition(serviceName, definition string) error {
+    path := pm.serviceDefinitionPath(serviceName)
+    if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+        return fmt.Errorf("failed to create directory for '%s': %w", serviceName, err)
+    }
+    return writeFileAtomic(path, []byte(definition), 0644)
+}
+
+// readGlobalProto loads and parses the global proto file. A missing file
+// yields the default base with no services.
+func (pm *ProtoManager) readGlobalProto() (*globalProto, string, error) {
+    data, err := os.ReadFile(pm.GlobalProtoPath)
+    if os.IsNotExist(err) {
+        return &globalProto{Base: defaultGlobalProtoBase, Services: make(map[string]string)}, "", nil
+    }
+    if err != nil {
+        return nil, "", err
+    }
+    gp, err := parseGlobalProto(string(data))
+    if err != nil {
+        return nil, "", fmt.Errorf("failed to parse global proto file '%s': %w", pm.GlobalProtoPath, err)
+    }
+    return gp, string(data), nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+    tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+
+    if _, err := tmp.Write(data); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Chmod(perm); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), path)
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
@@ -0,0 +1,209 @@
+// protomanager/gomod.go
+package protomanager
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "go/parser"
+    "go/token"
+    "io/fs"
+    "os"
+    "path"
+    "path/filepath"
+    "strconv"
+    "strings"
+)
+
+// Go module layouts for generated code.
+const (
+    // GoModulePerPackage writes a go.mod into each package's Go output directory.
+    GoModulePerPackage = "package"
+    // GoModuleSingle writes one go.mod at the root of OutputDir.
+    GoModuleSingle = "single"
+)
+
+// GoModuleConfig configures go.mod scaffolding for generated Go code.
+type GoModuleConfig struct {
+    // Mode is "package", "single" or empty to disable scaffolding.
+    Mode string `yaml:"mode"`
+    // Path is a text/template for the module path, rendered with
+    // FileOptionsData. It defaults to "{{.Module}}/{{.Domain}}/{{.Service}}"
+    // per package and "{{.Module}}" for a single module.
+    Path string `yaml:"path"`
+    // GoVersion is written to the go directive.
+    GoVersion string `yaml:"go_version"`
+    // Require pins runtime modules by path. Only those imported by the
+    // generated code are written to go.mod.
+    Require map[string]string `yaml:"require"`
+    // Tidy runs `go mod tidy` after go.mod is written, which records
+    // indirect dependencies and writes go.sum. It needs the module cache or
+    // network access.
+    Tidy bool `yaml:"tidy"`
+}
+
+// scaffoldGoModule creates or updates the go.mod covering the Go code of
+// target and, with Tidy set, runs `go mod tidy` on it. It returns go.mod and
+// go.sum when they belong to target (per package mode), so they can be
+// listed in the output manifest.
+func (pm *ProtoManager) scaffoldGoModule(ctx context.Context, target OutputTarget) ([]string, error) {
+    cfg := pm.Config.GoModule
+    if cfg.Mode == "" || !goLanguages[target.Language] {
+        return nil, nil
+    }
+
+    var dir, defaultPath string
+    data := FileOptionsData{Module: pm.Config.Module}
+    switch cfg.Mode {
+    case GoModulePerPackage:
+        dir, defaultPath = target.Dir, "{{.Module}}/{{.Domain}}/{{.Service}}"
+        data.Service, data.Domain, data.Version = target.Package, target.Domain, target.Version
+    case GoModuleSingle:
+        dir, defaultPath = pm.OutputDir, "{{.Module}}"
+    default:
+        return nil, fmt.Errorf("unknown go_module mode '%s'; use package or single", cfg.Mode)
+    }
+    if pm.Config.Module == "" {
+        return nil, fmt.Errorf("go_module requires `module` to be set in the config file")
+    }
+
+    tmpl := cfg.Path
+    if tmpl == "" {
+        tmpl = defaultPath
+    }
+    rendered, err := renderFileOptions(map[string]string{"go_module": tmpl}, data)
+    if err != nil {
+        return nil, err
+    }
+    modulePath := path.Clean(rendered["go_module"])
+
+    pm.goModMu.Lock()
+    defer pm.goModMu.Unlock()
+
+    imports, err := goImports(dir)
+    if err != nil {
+        return nil, err
+    }
+
+    var require []string
+    for _, mod := range sortedKeys(cfg.Require) {
+        if importsModule(imports, mod) {
+            require = append(require, fmt.Sprintf("\t%s %s", mod, cfg.Require[mod]))
+        }
+    }
+
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "module %s\n\ngo %s\n", modulePath, cfg.GoVersion)
+    if len(require) > 0 {
+        fmt.Fprintf(&sb, "\nrequire (\n%s\n)\n", strings.Join(require, "\n"))
+    }
+
+    // A tidied go.mod differs from the rendered one, so the rendered file is
+    // written and tidied again and the result compared with what was there.
+    goMod := filepath.Join(dir, "go.mod")
+    goSum := filepath.Join(dir, "go.sum")
+    existing, err := readFileIfExists(goMod)
+    if err != nil {
+        return nil, err
+    }
+    if string(existing) != sb.String() {
+        if err := writeFileAtomic(goMod, []byte(sb.String()), 0644); err != nil {
+            return nil, err
+        }
+    }
+    if cfg.Tidy {
+        if err := pm.tidyGoModule(ctx, dir); err != nil {
+            return nil, err
+        }
+    }
+    updated, err := os.ReadFile(goMod)
+    if err != nil {
+        return nil, err
+    }
+    if !bytes.Equal(existing, updated) {
+        pm.Logger.Infof("Updated '%s' for module '%s'", goMod, modulePath)
+        pm.emitEvent(Event{Type: "GoModuleUpdated", Message: fmt.Sprintf("Updated '%s' for module '%s'", goMod, modulePath)})
+    }
+
+    if cfg.Mode == GoModuleSingle {
+        return nil, nil
+    }
+    files := []string{goMod}
+    if _, err := os.Stat(goSum); err == nil {
+        files = append(files, goSum)
+    }
+    return files, nil
+}
+
+// tidyGoModule runs `go mod tidy` in dir, bounded by the hook timeout.
+func (pm *ProtoManager) tidyGoModule(ctx context.Context, dir string) error {
+    cmd := NewCommand(ctx, pm.Config.Timeouts.Hook, "go", "mod", "tidy")
+    cmd.Dir = dir
+    output, err := cmd.CombinedOutput()
+    if err != nil {
+        return fmt.Errorf("go mod tidy failed in '%s': %w\n%s", dir, err, strings.TrimSpace(string(output)))
+    }
+    return nil
+}
+
+// goModuleRoot returns the directory of the go.mod covering dir, or "" if
+// dir is not inside a Go module.
+func goModuleRoot(dir string) string {
+    for {
+        if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+            return dir
+        }
+        parent := filepath.Dir(dir)
+        if parent == dir {
+            return ""
+        }
+        dir = parent
+    }
+}
+
+// goImports returns the import paths used by the .go files under dir,
+// skipping nested directories that are modules of their own.
+func goImports(dir string) (map[string]bool, error) {
+    imports := map[string]bool{}
+    fset := token.NewFileSet()
+    err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if d.IsDir() {
+            if p != dir {
+                if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+                    return filepath.SkipDir
+                }
+            }
+            return nil
+        }
+        if filepath.Ext(p) != ".go" {
+            return nil
+        }
+        file, err := parser.ParseFile(fset, p, nil, parser.ImportsOnly)
+        if err != nil {
+            return err
+        }
+        for _, spec := range file.Imports {
+            if name, err := strconv.Unquote(spec.Path.Value); err == nil {
+                imports[name] = true
+            }
+        }
+        return nil
+    })
+    if err != nil && !os.IsNotExist(err) {
+        return nil, err
+    }
+    return imports, nil
+}
+
+// importsModule reports whether any import path belongs to module mod.
+func importsModule(imports map[string]bool, mod string) bool {
+    for name := range imports {
+        if name == mod || strings.HasPrefix(name, mod+"/") {
+            return true
+        }
+    }
+    return false
+}
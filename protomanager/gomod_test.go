@@ -0,0 +1,48 @@
+// protomanager/gomod_test.go
+package protomanager
+
+import (
+    "context"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestScaffoldGoModuleTidies(t *testing.T) {
+    if _, err := exec.LookPath("go"); err != nil {
+        t.Skip("go is not installed")
+    }
+    pm := newTestProtoManager(t)
+    pm.Config.Module = "example.com/registry"
+    pm.Config.GoModule.Mode = GoModulePerPackage
+    pm.Config.GoModule.GoVersion = "1.20"
+    pm.Config.GoModule.Tidy = true
+
+    target := OutputTarget{Package: "billing", Language: "go", Domain: "pay", Dir: filepath.Join(pm.OutputDir, "billing", "go")}
+    if err := os.MkdirAll(target.Dir, os.ModePerm); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(filepath.Join(target.Dir, "billing.go"), []byte("package billing\n\nimport _ \"strings\"\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    files, err := pm.scaffoldGoModule(context.Background(), target)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(files) == 0 || files[0] != filepath.Join(target.Dir, "go.mod") {
+        t.Fatalf("scaffoldGoModule returned %v, want go.mod first", files)
+    }
+    data, err := os.ReadFile(files[0])
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.HasPrefix(string(data), "module example.com/registry/pay/billing\n") {
+        t.Errorf("unexpected go.mod:\n%s", data)
+    }
+    if goModuleRoot(filepath.Join(target.Dir, "sub")) != target.Dir {
+        t.Errorf("goModuleRoot does not find the scaffolded module")
+    }
+}
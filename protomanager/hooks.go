@@ -0,0 +1,107 @@
+// protomanager/hooks.go
+package protomanager
+
+import (
+    "context"
+    "fmt"
+    "strings"
+)
+
+// builtinHooks are the commands run by the built-in post-generation hooks.
+var builtinHooks = map[string][]string{
+    "gofmt":     {"gofmt", "-l", "-w", "."},
+    "goimports": {"goimports", "-w", "."},
+    "go-vet":    {"go", "vet", "./..."},
+    "go-build":  {"go", "build", "./..."},
+}
+
+// moduleHooks are the built-in hooks that need a go.mod covering the output
+// directory, normally scaffolded through go_module.mode.
+var moduleHooks = map[string]bool{"go-vet": true, "go-build": true}
+
+// HookConfig is a step run in a language's output directory after generation.
+// Exactly one of Builtin and Command is set.
+type HookConfig struct {
+    Name    string   `yaml:"name"`    // Used in diagnostics; defaults to Builtin or the command
+    Builtin string   `yaml:"builtin"` // gofmt, goimports, go-vet or go-build
+    Command []string `yaml:"command"` // Arbitrary command and arguments
+}
+
+// HookError reports a failed post-generation hook.
+type HookError struct {
+    Target      string // Generation target, e.g. "billing/go"
+    Hook        string
+    Diagnostics []Diagnostic
+}
+
+// Error implements the error interface.
+func (e *HookError) Error() string {
+    lines := make([]string, 0, len(e.Diagnostics))
+    for _, d := range e.Diagnostics {
+        lines = append(lines, "  "+d.String())
+    }
+    return fmt.Sprintf("post-generation hook '%s' failed for '%s':\n%s", e.Hook, e.Target, strings.Join(lines, "\n"))
+}
+
+// name returns the hook's display name.
+func (h HookConfig) name() string {
+    switch {
+    case h.Name != "":
+        return h.Name
+    case h.Builtin != "":
+        return h.Builtin
+    default:
+        return strings.Join(h.Command, " ")
+    }
+}
+
+// command returns the command line run by the hook.
+func (h HookConfig) command() ([]string, error) {
+    switch {
+    case h.Builtin != "" && len(h.Command) > 0:
+        return nil, fmt.Errorf("hook '%s' sets both builtin and command", h.name())
+    case h.Builtin != "":
+        args, ok := builtinHooks[h.Builtin]
+        if !ok {
+            return nil, fmt.Errorf("unknown builtin hook '%s'; use gofmt, goimports, go-vet or go-build", h.Builtin)
+        }
+        return args, nil
+    case len(h.Command) > 0:
+        return h.Command, nil
+    default:
+        return nil, fmt.Errorf("hook '%s' sets neither builtin nor command", h.name())
+    }
+}
+
+// runHooks runs the post-generation hooks of target in target.Dir, stopping
+// at the first failure with a *HookError.
+func (pm *ProtoManager) runHooks(ctx context.Context, target OutputTarget) error {
+    for _, hook := range target.Hooks {
+        args, err := hook.command()
+        if err != nil {
+            return err
+        }
+
+        var diagnostics []Diagnostic
+        if moduleHooks[hook.Builtin] && goModuleRoot(target.Dir) == "" {
+            diagnostics = []Diagnostic{{Source: hook.name(), Severity: "error", Message: fmt.Sprintf("no go.mod covers '%s'; set go_module.mode to scaffold one", target.Dir)}}
+        } else {
+            pm.Logger.Infof("Running post-generation hook '%s' for '%s'", hook.name(), target.key())
+            cmd := NewCommand(ctx, pm.Config.Timeouts.Hook, args[0], args[1:]...)
+            cmd.Dir = target.Dir
+            output, err := cmd.CombinedOutput()
+            if err == nil {
+                continue
+            }
+            diagnostics = locateDiagnostics(parseDiagnostics(hook.name(), string(output)), []string{target.Dir})
+            if len(diagnostics) == 0 {
+                diagnostics = []Diagnostic{{Source: hook.name(), Severity: "error", Message: err.Error()}}
+            }
+        }
+        hookErr := &HookError{Target: target.key(), Hook: hook.name(), Diagnostics: diagnostics}
+        pm.Logger.Errorf("%v", hookErr)
+        pm.emitEvent(Event{Type: "HookFailed", Message: hookErr.Error(), Payload: diagnostics})
+        return hookErr
+    }
+    return nil
+}
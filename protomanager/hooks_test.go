@@ -0,0 +1,29 @@
+// protomanager/hooks_test.go
+package protomanager
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+)
+
+func TestGoHooksNeedModule(t *testing.T) {
+    pm := newTestProtoManager(t)
+    dir := t.TempDir()
+    if goModuleRoot(dir) != "" {
+        t.Skip("the temporary directory is inside a Go module")
+    }
+
+    for _, builtin := range []string{"go-vet", "go-build"} {
+        target := OutputTarget{Package: "billing", Language: "go", Dir: dir, Hooks: []HookConfig{{Builtin: builtin}}}
+        err := pm.runHooks(context.Background(), target)
+        var hookErr *HookError
+        if !errors.As(err, &hookErr) {
+            t.Fatalf("%s without go.mod: got %v, want a *HookError", builtin, err)
+        }
+        if len(hookErr.Diagnostics) != 1 || !strings.Contains(hookErr.Diagnostics[0].Message, "go_module.mode") {
+            t.Errorf("%s without go.mod: unexpected diagnostics %v", builtin, hookErr.Diagnostics)
+        }
+    }
+}
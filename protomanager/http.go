@@ -0,0 +1,227 @@
+// protomanager/http.go
+package protomanager
+
+import (
+    "bytes"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "text/template"
+
+    "github.com/bufbuild/protocompile/ast"
+    "github.com/bufbuild/protocompile/parser"
+    "github.com/bufbuild/protocompile/reporter"
+)
+
+const (
+    // httpOption is the method option read by grpc-gateway and the OpenAPI plugins.
+    httpOption = "google.api.http"
+    // httpAnnotationsProto declares httpOption.
+    httpAnnotationsProto = "google/api/annotations.proto"
+)
+
+// HTTPConfig configures the HTTP mapping of the REST targets (gateway,
+// openapiv2 and openapiv3).
+type HTTPConfig struct {
+    // DefaultRule maps methods that have no google.api.http option.
+    DefaultRule HTTPRuleConfig `yaml:"default_rule"`
+}
+
+// HTTPRuleConfig describes a google.api.http rule.
+type HTTPRuleConfig struct {
+    // Method is get, put, post, delete or patch. Empty disables the default mapping.
+    Method string `yaml:"method"`
+    // Path is a text/template rendered with HTTPRuleData.
+    Path string `yaml:"path"`
+    // Body is the request field sent as the HTTP body, "*" for the whole
+    // request. It is ignored for get and delete.
+    Body string `yaml:"body"`
+}
+
+// HTTPRuleData is the data passed to HTTPRuleConfig.Path.
+type HTTPRuleData struct {
+    Package      string // Registered package name
+    Domain       string
+    Version      string
+    ProtoPackage string // Proto package declared in the file
+    ProtoService string // Service name declared in the file
+    Method       string // RPC name
+}
+
+// WithDefaultHTTPRules returns req with every method lacking a
+// google.api.http option mapped by the configured default rule. Files that
+// need rules are rewritten into a scratch directory that shadows the
+// originals on the include path; call the returned function to remove it
+// once generation is done. The rewritten files import
+// google/api/annotations.proto, so it fails when no include path provides it.
+func (pm *ProtoManager) WithDefaultHTTPRules(packageName string, metadata ServiceMetadata, req GenerateRequest) (GenerateRequest, func(), error) {
+    rule := pm.Config.HTTP.DefaultRule
+    if rule.Method == "" {
+        return req, func() {}, nil
+    }
+    switch rule.Method {
+    case "get", "put", "post", "delete", "patch":
+    default:
+        return req, func() {}, fmt.Errorf("unknown HTTP method '%s' in http.default_rule; use get, put, post, delete or patch", rule.Method)
+    }
+    tmpl, err := template.New("http").Funcs(templateFuncs).Option("missingkey=error").Parse(rule.Path)
+    if err != nil {
+        return req, func() {}, fmt.Errorf("failed to parse http.default_rule.path: %w", err)
+    }
+
+    scratch, err := os.MkdirTemp("", "protomanager-http-*")
+    if err != nil {
+        return req, func() {}, err
+    }
+    cleanup := func() { os.RemoveAll(scratch) }
+
+    data := HTTPRuleData{Package: packageName, Domain: metadata.Domain, Version: metadata.Version}
+    resolver := &ImportResolver{IncludePaths: req.IncludePaths}
+    annotated := req
+    annotated.IncludePaths = append([]string{scratch}, req.IncludePaths...)
+    annotated.Files = make([]string, len(req.Files))
+    for i, file := range req.Files {
+        annotated.Files[i] = file
+
+        name, err := resolver.NameOf(file)
+        if err != nil {
+            cleanup()
+            return req, func() {}, err
+        }
+        content, err := os.ReadFile(file)
+        if err != nil {
+            cleanup()
+            return req, func() {}, err
+        }
+        rewritten, err := applyHTTPRules(name, content, rule, tmpl, data)
+        if err != nil {
+            cleanup()
+            return req, func() {}, err
+        }
+        if rewritten == nil {
+            continue
+        }
+        if _, ok := resolver.Find(httpAnnotationsProto); !ok {
+            cleanup()
+            return req, func() {}, fmt.Errorf("http.default_rule maps methods of '%s' but '%s' is not in the include paths; vendor googleapis or clear http.default_rule.method", name, httpAnnotationsProto)
+        }
+
+        out := filepath.Join(scratch, filepath.FromSlash(name))
+        if err := os.MkdirAll(filepath.Dir(out), os.ModePerm); err != nil {
+            cleanup()
+            return req, func() {}, err
+        }
+        if err := os.WriteFile(out, rewritten, 0644); err != nil {
+            cleanup()
+            return req, func() {}, err
+        }
+        annotated.Files[i] = out
+    }
+    return annotated, cleanup, nil
+}
+
+// applyHTTPRules adds the default rule to every unannotated method in the
+// proto file content and imports the annotations it needs. It returns nil
+// when nothing needs to change, including when the file does not parse; the
+// compiler reports the syntax error with its position later.
+func applyHTTPRules(name string, content []byte, rule HTTPRuleConfig, tmpl *template.Template, data HTTPRuleData) ([]byte, error) {
+    file, err := parser.Parse(name, bytes.NewReader(content), reporter.NewHandler(nil))
+    if err != nil {
+        return nil, nil
+    }
+
+    type edit struct {
+        offset, length int
+        text           string
+    }
+    var edits []edit
+    offset := func(n ast.Node) int { return file.NodeInfo(n).Start().Offset }
+
+    imported := false
+    importAt := 0
+    if file.Syntax != nil {
+        importAt = offset(file.Syntax.Semicolon) + 1
+    } else if file.Edition != nil {
+        importAt = offset(file.Edition.Semicolon) + 1
+    }
+    for _, decl := range file.Decls {
+        switch n := decl.(type) {
+        case *ast.PackageNode:
+            data.ProtoPackage = string(n.Name.AsIdentifier())
+            importAt = offset(n.Semicolon) + 1
+        case *ast.ImportNode:
+            imported = imported || n.Name.AsString() == httpAnnotationsProto
+        }
+    }
+
+    for _, decl := range file.Decls {
+        service, ok := decl.(*ast.ServiceNode)
+        if !ok {
+            continue
+        }
+        data.ProtoService = service.Name.Val
+        for _, element := range service.Decls {
+            rpc, ok := element.(*ast.RPCNode)
+            if !ok || hasHTTPOption(rpc) {
+                continue
+            }
+            data.Method = rpc.Name.Val
+            option, err := renderHTTPRule(rule, tmpl, data)
+            if err != nil {
+                return nil, err
+            }
+            if rpc.Semicolon != nil {
+                edits = append(edits, edit{offset(rpc.Semicolon), 1, " {\n    " + option + "\n  }"})
+            } else if len(rpc.Decls) == 0 {
+                edits = append(edits, edit{offset(rpc.CloseBrace), 0, "\n    " + option + "\n  "})
+            } else {
+                edits = append(edits, edit{offset(rpc.CloseBrace), 0, "  " + option + "\n  "})
+            }
+        }
+    }
+    if len(edits) == 0 {
+        return nil, nil
+    }
+    if !imported {
+        edits = append(edits, edit{importAt, 0, fmt.Sprintf("\n\nimport %q;", httpAnnotationsProto)})
+    }
+
+    // Apply from the end so earlier offsets stay valid
+    sort.SliceStable(edits, func(i, j int) bool { return edits[i].offset > edits[j].offset })
+    out := append([]byte{}, content...)
+    for _, e := range edits {
+        out = append(out[:e.offset], append([]byte(e.text), out[e.offset+e.length:]...)...)
+    }
+    return out, nil
+}
+
+// hasHTTPOption reports whether rpc already sets google.api.http.
+func hasHTTPOption(rpc *ast.RPCNode) bool {
+    for _, element := range rpc.Decls {
+        option, ok := element.(*ast.OptionNode)
+        if !ok || len(option.Name.Parts) == 0 {
+            continue
+        }
+        part := option.Name.Parts[0]
+        if part.IsExtension() && strings.TrimPrefix(string(part.Name.AsIdentifier()), ".") == httpOption {
+            return true
+        }
+    }
+    return false
+}
+
+// renderHTTPRule renders the google.api.http option statement for a method.
+func renderHTTPRule(rule HTTPRuleConfig, tmpl *template.Template, data HTTPRuleData) (string, error) {
+    var path strings.Builder
+    if err := tmpl.Execute(&path, data); err != nil {
+        return "", fmt.Errorf("failed to render HTTP path for '%s.%s': %w", data.ProtoService, data.Method, err)
+    }
+
+    fields := fmt.Sprintf("%s: %q", rule.Method, path.String())
+    if rule.Body != "" && rule.Method != "get" && rule.Method != "delete" {
+        fields += fmt.Sprintf(" body: %q", rule.Body)
+    }
+    return fmt.Sprintf("option (%s) = { %s };", httpOption, fields), nil
+}
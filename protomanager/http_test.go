@@ -0,0 +1,100 @@
+// protomanager/http_test.go
+package protomanager
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "text/template"
+)
+
+func TestApplyHTTPRules(t *testing.T) {
+    rule := HTTPRuleConfig{Method: "post", Path: "/{{.ProtoPackage}}.{{.ProtoService}}/{{.Method}}", Body: "*"}
+    tmpl := template.Must(template.New("http").Parse(rule.Path))
+    header := "syntax = \"proto3\";\n\npackage pay;\n"
+    annotations := "import \"google/api/annotations.proto\";\n"
+    mapped := "option (google.api.http) = { post: \"/pay.Billing/Charge\" body: \"*\" };"
+
+    tests := []struct {
+        name string
+        src  string
+        want string // empty when the file must be left unchanged
+    }{
+        {
+            name: "unannotated method",
+            src:  header + "service Billing {\n  rpc Charge(A) returns (B);\n}\n",
+            want: "syntax = \"proto3\";\n\npackage pay;\n\nimport \"google/api/annotations.proto\";\nservice Billing {\n  rpc Charge(A) returns (B) {\n    " + mapped + "\n  }\n}\n",
+        },
+        {
+            name: "existing google.api.http option",
+            src:  header + annotations + "service Billing {\n  rpc Charge(A) returns (B) {\n    option (google.api.http) = { get: \"/charges\" };\n  }\n}\n",
+        },
+        {
+            name: "options block without http rule",
+            src:  header + annotations + "service Billing {\n  rpc Charge(A) returns (B) {\n    option deprecated = true;\n  }\n}\n",
+            want: header + annotations + "service Billing {\n  rpc Charge(A) returns (B) {\n    option deprecated = true;\n    " + mapped + "\n  }\n}\n",
+        },
+        {
+            name: "comments containing braces",
+            src:  header + annotations + "// service Fake { rpc X(A) returns (B); }\nservice Billing {\n  /* } */\n  rpc Charge(A) returns (B) {} // {\n}\n",
+            want: header + annotations + "// service Fake { rpc X(A) returns (B); }\nservice Billing {\n  /* } */\n  rpc Charge(A) returns (B) {\n    " + mapped + "\n  } // {\n}\n",
+        },
+        {
+            name: "syntax error",
+            src:  header + "service Billing {\n  rpc Charge(A) returns (B)\n",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := applyHTTPRules("pay/billing.proto", []byte(tt.src), rule, tmpl, HTTPRuleData{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if tt.want == "" {
+                if got != nil {
+                    t.Errorf("applyHTTPRules() =\n%s\nwant the file unchanged", got)
+                }
+                return
+            }
+            if string(got) != tt.want {
+                t.Errorf("applyHTTPRules() =\n%s\nwant\n%s", got, tt.want)
+            }
+        })
+    }
+}
+
+// TestDefaultHTTPRulesNeedAnnotations checks that the default rule fails
+// before generation when google/api/annotations.proto cannot be imported.
+func TestDefaultHTTPRulesNeedAnnotations(t *testing.T) {
+    pm := newTestProtoManager(t)
+    dir := t.TempDir()
+    file := filepath.Join(dir, "billing.proto")
+    src := "syntax = \"proto3\";\npackage pay;\nmessage A {}\nservice Billing {\n  rpc Charge(A) returns (A);\n}\n"
+    if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    req := GenerateRequest{IncludePaths: []string{dir}, Files: []string{file}}
+    _, cleanup, err := pm.WithDefaultHTTPRules("billing", ServiceMetadata{}, req)
+    cleanup()
+    if err == nil || !strings.Contains(err.Error(), "vendor googleapis") {
+        t.Fatalf("WithDefaultHTTPRules() error = %v, want a missing annotations error", err)
+    }
+
+    annotations := filepath.Join(dir, filepath.FromSlash(httpAnnotationsProto))
+    if err := os.MkdirAll(filepath.Dir(annotations), os.ModePerm); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(annotations, []byte("syntax = \"proto3\";\npackage google.api;\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    annotated, cleanup, err := pm.WithDefaultHTTPRules("billing", ServiceMetadata{}, req)
+    defer cleanup()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if annotated.Files[0] == file {
+        t.Error("WithDefaultHTTPRules() did not rewrite the file")
+    }
+}
@@ -0,0 +1,213 @@
+// protomanager/imports.go
+package protomanager
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/bufbuild/protocompile"
+)
+
+// ProtoFile is a proto file located on disk together with the name it is
+// imported by, relative to the include path that contains it.
+type ProtoFile struct {
+    Name string // Import name, e.g. "google/protobuf/timestamp.proto"
+    Path string // Location on disk
+}
+
+// ImportResolver resolves proto imports against an ordered list of include
+// paths, the same way protoc resolves its --proto_path arguments.
+type ImportResolver struct {
+    IncludePaths []string
+}
+
+// MissingImportError reports imports that could not be found in any include path.
+type MissingImportError struct {
+    Missing      map[string][]string // Import name -> files importing it
+    IncludePaths []string
+}
+
+// Error implements the error interface.
+func (e *MissingImportError) Error() string {
+    var parts []string
+    for _, name := range sortedKeys(e.Missing) {
+        parts = append(parts, fmt.Sprintf("'%s' (imported by %s)", name, strings.Join(e.Missing[name], ", ")))
+    }
+    return fmt.Sprintf("unresolved imports %s in include paths [%s]; run `protomanager vendor` to populate the vendor cache",
+        strings.Join(parts, ", "), strings.Join(e.IncludePaths, ", "))
+}
+
+// Find returns the file an import name resolves to.
+func (r *ImportResolver) Find(name string) (ProtoFile, bool) {
+    for _, dir := range r.IncludePaths {
+        path := filepath.Join(dir, filepath.FromSlash(name))
+        if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+            return ProtoFile{Name: name, Path: path}, true
+        }
+    }
+    return ProtoFile{}, false
+}
+
+// NameOf returns the import name of a file on disk, relative to the first
+// include path containing it.
+func (r *ImportResolver) NameOf(path string) (string, error) {
+    abs, err := filepath.Abs(path)
+    if err != nil {
+        return "", err
+    }
+    for _, dir := range r.IncludePaths {
+        absDir, err := filepath.Abs(dir)
+        if err != nil {
+            return "", err
+        }
+        rel, err := filepath.Rel(absDir, abs)
+        if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+            return filepath.ToSlash(rel), nil
+        }
+    }
+    return "", fmt.Errorf("file '%s' does not reside within any include path [%s]", path, strings.Join(r.IncludePaths, ", "))
+}
+
+// standardImports finds only the well-known types bundled with the pure-Go compiler.
+var standardImports = protocompile.WithStandardImports(protocompile.ResolverFunc(func(string) (protocompile.SearchResult, error) {
+    return protocompile.SearchResult{}, os.ErrNotExist
+}))
+
+// isStandardImport reports whether name is a well-known type bundled with the
+// compiler, which resolves even when no include path contains it.
+func isStandardImport(name string) bool {
+    _, err := standardImports.FindFileByPath(name)
+    return err == nil
+}
+
+// Resolve returns the transitive closure of files and their imports, sorted
+// by import name. Well-known types missing from the include paths resolve to
+// the copies bundled with the compiler and are left out of the result. It
+// fails with a *MissingImportError if any other import cannot be found.
+func (r *ImportResolver) Resolve(files []string) ([]ProtoFile, error) {
+    seen := map[string]ProtoFile{}
+    missing := map[string][]string{}
+
+    var queue []ProtoFile
+    for _, path := range files {
+        name, err := r.NameOf(path)
+        if err != nil {
+            return nil, err
+        }
+        queue = append(queue, ProtoFile{Name: name, Path: path})
+    }
+
+    for len(queue) > 0 {
+        file := queue[0]
+        queue = queue[1:]
+        if _, ok := seen[file.Name]; ok {
+            continue
+        }
+        seen[file.Name] = file
+
+        imports, err := parseImports(file.Path)
+        if err != nil {
+            return nil, err
+        }
+        for _, name := range imports {
+            if _, ok := seen[name]; ok {
+                continue
+            }
+            dep, ok := r.Find(name)
+            if !ok && isStandardImport(name) {
+                continue
+            }
+            if !ok {
+                missing[name] = append(missing[name], file.Name)
+                continue
+            }
+            queue = append(queue, dep)
+        }
+    }
+
+    if len(missing) > 0 {
+        return nil, &MissingImportError{Missing: missing, IncludePaths: r.IncludePaths}
+    }
+
+    resolved := make([]ProtoFile, 0, len(seen))
+    for _, name := range sortedKeys(seen) {
+        resolved = append(resolved, seen[name])
+    }
+    return resolved, nil
+}
+
+// parseImports returns the import names declared by the proto file at path.
+func parseImports(path string) ([]string, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+
+    var imports []string
+    var scan protoScanner
+    for _, line := range strings.Split(string(data), "\n") {
+        if scan.depth == 0 && !scan.inComment {
+            if m := importPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+                imports = append(imports, m[1])
+            }
+        }
+        scan.scan(line)
+    }
+    return imports, nil
+}
+
+// IncludePaths returns the ordered, de-duplicated include paths used for code
+// generation: the given directories first, then the microservice proto
+// directory, the directory of the global proto file, the configured include
+// paths and finally the vendor cache.
+func (pm *ProtoManager) IncludePaths(dirs ...string) []string {
+    candidates := append([]string{}, dirs...)
+    candidates = append(candidates, pm.MicroserviceProtoDir, filepath.Dir(pm.GlobalProtoPath))
+    candidates = append(candidates, pm.Config.IncludePaths...)
+    candidates = append(candidates, pm.Config.Vendor.CacheDir)
+
+    seen := map[string]bool{}
+    var paths []string
+    for _, dir := range candidates {
+        if dir == "" {
+            continue
+        }
+        clean := filepath.Clean(dir)
+        if seen[clean] {
+            continue
+        }
+        seen[clean] = true
+        paths = append(paths, clean)
+    }
+    return paths
+}
+
+// ResolveImports resolves the transitive imports of files against the
+// include paths, emitting an error event when an import cannot be found.
+func (pm *ProtoManager) ResolveImports(includePaths []string, files ...string) ([]ProtoFile, error) {
+    resolver := &ImportResolver{IncludePaths: includePaths}
+    resolved, err := resolver.Resolve(files)
+    if err != nil {
+        pm.Logger.Errorf("Failed to resolve imports: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to resolve imports: %v", err)})
+        return nil, err
+    }
+
+    names := make([]string, 0, len(resolved))
+    for _, file := range resolved {
+        names = append(names, file.Name)
+    }
+    pm.Logger.Debugf("Resolved %d proto file(s): %s", len(names), strings.Join(names, ", "))
+    return resolved, nil
+}
+
+// protocPathArgs returns --proto_path arguments for the include paths.
+func protocPathArgs(includePaths []string) []string {
+    args := make([]string, 0, len(includePaths))
+    for _, dir := range includePaths {
+        args = append(args, "--proto_path="+dir)
+    }
+    return args
+}
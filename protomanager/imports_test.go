@@ -0,0 +1,68 @@
+// protomanager/imports_test.go
+package protomanager
+
+import (
+    "errors"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+// writeProtos writes files, keyed by path relative to dir, with the given contents.
+func writeProtos(t *testing.T, dir string, files map[string]string) {
+    t.Helper()
+    for name, content := range files {
+        path := filepath.Join(dir, filepath.FromSlash(name))
+        if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+}
+
+func TestImportResolverResolve(t *testing.T) {
+    dir := t.TempDir()
+    local, vendor := filepath.Join(dir, "proto"), filepath.Join(dir, "vendor")
+    writeProtos(t, local, map[string]string{
+        "billing/billing.proto": "syntax = \"proto3\";\nimport \"billing/money.proto\";\nimport \"google/api/annotations.proto\";\n",
+        "billing/money.proto":   "syntax = \"proto3\";\nimport \"google/protobuf/timestamp.proto\";\n",
+        "orders/orders.proto":   "syntax = \"proto3\";\nimport \"billing/money.proto\";\nimport \"missing/a.proto\";\n// import \"commented/out.proto\";\n",
+    })
+    writeProtos(t, vendor, map[string]string{
+        "google/api/annotations.proto": "syntax = \"proto3\";\nimport \"google/api/http.proto\";\nimport \"google/protobuf/descriptor.proto\";\n",
+        "google/api/http.proto":        "syntax = \"proto3\";\n",
+        // Shadowed by the local include path.
+        "billing/money.proto": "syntax = \"proto3\";\nimport \"missing/b.proto\";\n",
+    })
+    resolver := &ImportResolver{IncludePaths: []string{local, vendor}}
+
+    resolved, err := resolver.Resolve([]string{filepath.Join(local, "billing", "billing.proto")})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []ProtoFile{
+        {Name: "billing/billing.proto", Path: filepath.Join(local, "billing", "billing.proto")},
+        {Name: "billing/money.proto", Path: filepath.Join(local, "billing", "money.proto")},
+        {Name: "google/api/annotations.proto", Path: filepath.Join(vendor, "google", "api", "annotations.proto")},
+        {Name: "google/api/http.proto", Path: filepath.Join(vendor, "google", "api", "http.proto")},
+    }
+    if !reflect.DeepEqual(resolved, want) {
+        t.Errorf("Resolve() =\n%v\nwant\n%v", resolved, want)
+    }
+
+    _, err = resolver.Resolve([]string{filepath.Join(local, "orders", "orders.proto"), filepath.Join(local, "billing", "billing.proto")})
+    var missing *MissingImportError
+    if !errors.As(err, &missing) {
+        t.Fatalf("Resolve() error = %v, want a *MissingImportError", err)
+    }
+    if want := map[string][]string{"missing/a.proto": {"orders/orders.proto"}}; !reflect.DeepEqual(missing.Missing, want) {
+        t.Errorf("Missing = %v, want %v", missing.Missing, want)
+    }
+
+    if _, err := resolver.Resolve([]string{filepath.Join(dir, "elsewhere.proto")}); err == nil {
+        t.Error("Resolve() accepted a file outside the include paths")
+    }
+}
@@ -1,15 +1,131 @@
-// protomanager/proto_registry.go
+// protomanager/internal_proto_registry.go
 package protomanager
 
-// ProtoRegistry defines the interface for registering and retrieving services.
-type ProtoRegistry interface {
-    RegisterService(serviceName string, metadata ServiceMetadata) error
-    GetService(serviceName string) (ServiceMetadata, error)
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+// InternalProtoRegistry is the default ProtoRegistry used when no external
+// registry is supplied. It keeps services in memory and, when created with
+// LoadInternalProtoRegistry, persists them to a JSON file after every change.
+type InternalProtoRegistry struct {
+    services map[string]ServiceMetadata
+    path     string
+    mu       sync.RWMutex
+}
+
+// NewInternalProtoRegistry initializes an empty in-memory registry.
+func NewInternalProtoRegistry() *InternalProtoRegistry {
+    return &InternalProtoRegistry{
+        services: make(map[string]ServiceMetadata),
+    }
+}
+
+// LoadInternalProtoRegistry initializes a registry backed by the JSON file at path.
+// A missing file yields an empty registry; the file is created on first write.
+func LoadInternalProtoRegistry(path string) (*InternalProtoRegistry, error) {
+    r := NewInternalProtoRegistry()
+    r.path = path
+
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return r, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read registry file '%s': %w", path, err)
+    }
+    if err := json.Unmarshal(data, &r.services); err != nil {
+        return nil, fmt.Errorf("failed to parse registry file '%s': %w", path, err)
+    }
+    if r.services == nil {
+        r.services = make(map[string]ServiceMetadata)
+    }
+    return r, nil
+}
+
+// RegisterService adds or replaces the metadata for serviceName.
+func (r *InternalProtoRegistry) RegisterService(serviceName string, metadata ServiceMetadata) error {
+    if serviceName == "" {
+        return fmt.Errorf("service name must not be empty")
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    services := r.copyServices()
+    services[serviceName] = metadata
+    return r.commit(services)
+}
+
+// GetService returns the metadata registered for serviceName.
+func (r *InternalProtoRegistry) GetService(serviceName string) (ServiceMetadata, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    metadata, ok := r.services[serviceName]
+    if !ok {
+        return ServiceMetadata{}, fmt.Errorf("service '%s' not found", serviceName)
+    }
+    return metadata, nil
+}
+
+// UnregisterService removes serviceName from the registry.
+func (r *InternalProtoRegistry) UnregisterService(serviceName string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, ok := r.services[serviceName]; !ok {
+        return fmt.Errorf("service '%s' not found", serviceName)
+    }
+    services := r.copyServices()
+    delete(services, serviceName)
+    return r.commit(services)
+}
+
+// ListServices returns a copy of all registered services keyed by name.
+func (r *InternalProtoRegistry) ListServices() (map[string]ServiceMetadata, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    return r.copyServices(), nil
+}
+
+// copyServices returns a copy of the registered services. Callers must hold r.mu.
+func (r *InternalProtoRegistry) copyServices() map[string]ServiceMetadata {
+    services := make(map[string]ServiceMetadata, len(r.services))
+    for name, metadata := range r.services {
+        services[name] = metadata
+    }
+    return services
+}
+
+// commit saves services and only then makes them the registry's state, so
+// memory never disagrees with the backing file. Callers must hold r.mu.
+func (r *InternalProtoRegistry) commit(services map[string]ServiceMetadata) error {
+    if err := r.save(services); err != nil {
+        return err
+    }
+    r.services = services
+    return nil
+}
+
+// save writes services to the registry's backing file, if it has one.
+func (r *InternalProtoRegistry) save(services map[string]ServiceMetadata) error {
+    if r.path == "" {
+        return nil
+    }
+
+    data, err := json.MarshalIndent(services, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to encode registry: %w", err)
+    }
+    if err := os.MkdirAll(filepath.Dir(r.path), os.ModePerm); err != nil {
+        return fmt.Errorf("failed to create registry directory: %w", err)
+    }
+    return writeFileAtomic(r.path, append(data, '\n'), 0644)
 }
 
-// ServiceMetadata holds metadata about a service.
-type ServiceMetadata struct {
-    Domain   string
-    Version  string
-    // Additional fields as needed
-}
\ No newline at end of file
+// Ensure that InternalProtoRegistry implements the ProtoRegistry interface
+var _ ProtoRegistry = (*InternalProtoRegistry)(nil)
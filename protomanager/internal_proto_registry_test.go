@@ -0,0 +1,40 @@
+// protomanager/internal_proto_registry_test.go
+package protomanager
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestInternalProtoRegistryKeepsStateWhenSaveFails(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "registry.json")
+    r, err := LoadInternalProtoRegistry(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := r.RegisterService("billing", ServiceMetadata{Domain: "pay", Version: "v1"}); err != nil {
+        t.Fatal(err)
+    }
+
+    // A file where the registry's directory should be makes every save fail.
+    r.path = filepath.Join(dir, "blocked", "registry.json")
+    if err := os.WriteFile(filepath.Join(dir, "blocked"), nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    if err := r.RegisterService("orders", ServiceMetadata{Domain: "shop", Version: "v1"}); err == nil {
+        t.Fatal("RegisterService succeeded although the registry could not be saved")
+    }
+    if _, err := r.GetService("orders"); err == nil {
+        t.Error("failed RegisterService left 'orders' registered in memory")
+    }
+
+    if err := r.UnregisterService("billing"); err == nil {
+        t.Fatal("UnregisterService succeeded although the registry could not be saved")
+    }
+    if _, err := r.GetService("billing"); err != nil {
+        t.Errorf("failed UnregisterService removed 'billing' from memory: %v", err)
+    }
+}
@@ -0,0 +1,363 @@
+// protomanager/jsonschema.go
+package protomanager
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "math"
+    "os"
+    "path/filepath"
+
+    "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+    // jsonSchemaDir is the subdirectory of OutputDir holding JSON Schemas.
+    jsonSchemaDir = "jsonschema"
+    // jsonSchemaExt is the extension of the schema written for each message.
+    jsonSchemaExt = ".schema.json"
+    // jsonSchemaDraft is the dialect of every schema written.
+    jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+)
+
+// jsonSchema is a JSON Schema object. encoding/json sorts its keys, so
+// schemas are written deterministically.
+type jsonSchema map[string]interface{}
+
+var (
+    // intPattern and uintPattern match the string form of protobuf integers.
+    intPattern  = "^-?[0-9]+$"
+    uintPattern = "^[0-9]+$"
+    // floatPattern matches the string form of float and double, including
+    // the special values.
+    floatPattern = `^(NaN|-?Infinity|-?[0-9]+(\.[0-9]*)?([eE][-+]?[0-9]+)?)$`
+    // durationPattern matches google.protobuf.Duration, e.g. "1.5s".
+    durationPattern = `^-?[0-9]+(\.[0-9]{1,9})?s$`
+)
+
+// JSONSchemaDir returns the directory holding the JSON Schemas of a service.
+func (pm *ProtoManager) JSONSchemaDir(serviceName string) string {
+    return filepath.Join(pm.OutputDir, jsonSchemaDir, serviceName)
+}
+
+// GenerateJSONSchemas writes a JSON Schema (draft 2020-12) for every message
+// of the named services, or of every registered service and the global proto
+// file when none are named, to JSONSchemaDir(service)/<message>.schema.json.
+// Schemas follow the proto3 JSON mapping and are self-contained: referenced
+// messages and enums are inlined under $defs. Each service's directory is
+// rewritten wholesale. It returns the paths written.
+func (pm *ProtoManager) GenerateJSONSchemas(ctx context.Context, serviceNames ...string) ([]string, error) {
+    services, err := pm.ProtoRegistry.ListServices()
+    if err != nil {
+        pm.Logger.Errorf("Failed to list registered services: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to list registered services: %v", err)})
+        return nil, err
+    }
+
+    selected := map[string]bool{}
+    for _, name := range serviceNames {
+        if _, ok := services[name]; !ok {
+            return nil, fmt.Errorf("service '%s' is not registered", name)
+        }
+        selected[name] = true
+    }
+    if len(selected) == 0 {
+        selected[globalOwner] = true
+        for name := range services {
+            selected[name] = true
+        }
+    }
+
+    declarations, err := pm.serviceDeclarations(ctx, services)
+    if err != nil {
+        pm.Logger.Errorf("Failed to compile protos for JSON Schema: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to compile protos for JSON Schema: %v", err)})
+        return nil, err
+    }
+
+    for _, name := range sortedKeys(selected) {
+        if err := os.RemoveAll(pm.JSONSchemaDir(name)); err != nil {
+            return nil, err
+        }
+    }
+
+    var written []string
+    for _, d := range declarations {
+        if !selected[d.Service] {
+            continue
+        }
+        var messages []protoreflect.MessageDescriptor
+        walkTypes(d.Desc, func(desc protoreflect.Descriptor) {
+            if md, ok := desc.(protoreflect.MessageDescriptor); ok {
+                messages = append(messages, md)
+            }
+        })
+
+        for _, md := range messages {
+            data, err := json.MarshalIndent(messageJSONSchema(md), "", "  ")
+            if err != nil {
+                return written, fmt.Errorf("failed to encode JSON Schema for '%s': %w", md.FullName(), err)
+            }
+            path := filepath.Join(pm.JSONSchemaDir(d.Service), string(md.FullName())+jsonSchemaExt)
+            if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+                return written, err
+            }
+            if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+                return written, err
+            }
+            written = append(written, path)
+        }
+    }
+
+    pm.Logger.Infof("Wrote %d JSON Schema(s) to '%s'", len(written), filepath.Join(pm.OutputDir, jsonSchemaDir))
+    pm.emitEvent(Event{Type: "JSONSchemasGenerated", Message: fmt.Sprintf("Wrote %d JSON Schema(s)", len(written))})
+    return written, nil
+}
+
+// messageJSONSchema returns the schema document for md: a reference to its
+// definition plus the definitions of every type it reaches.
+func messageJSONSchema(md protoreflect.MessageDescriptor) jsonSchema {
+    b := &schemaBuilder{defs: jsonSchema{}}
+    root := b.message(md)
+    root["$schema"] = jsonSchemaDraft
+    root["$id"] = string(md.FullName()) + jsonSchemaExt
+    root["$defs"] = b.defs
+    return root
+}
+
+// schemaBuilder collects the $defs of one schema document.
+type schemaBuilder struct {
+    defs jsonSchema
+}
+
+// ref returns a reference to the definition named by name.
+func (b *schemaBuilder) ref(name protoreflect.FullName) jsonSchema {
+    return jsonSchema{"$ref": "#/$defs/" + string(name)}
+}
+
+// message returns the schema of a message value: the proto3 JSON form of
+// well-known types, otherwise a reference to its object definition.
+func (b *schemaBuilder) message(md protoreflect.MessageDescriptor) jsonSchema {
+    if schema, ok := b.wellKnown(md); ok {
+        return schema
+    }
+    name := md.FullName()
+    if _, ok := b.defs[string(name)]; ok {
+        return b.ref(name)
+    }
+    // Register before descending so recursive messages terminate
+    def := jsonSchema{"type": "object", "title": relativeName(md)}
+    b.defs[string(name)] = def
+    describe(def, md)
+
+    properties := jsonSchema{}
+    var required []string
+    for i := 0; i < md.Fields().Len(); i++ {
+        fd := md.Fields().Get(i)
+        schema := b.field(fd)
+        describe(schema, fd)
+        // protojson accepts the lowerCamelCase JSON name and the proto name
+        for _, key := range fieldJSONNames(fd) {
+            properties[key] = schema
+        }
+        if fd.Cardinality() == protoreflect.Required {
+            required = append(required, fd.JSONName())
+        }
+    }
+    def["properties"] = properties
+    def["additionalProperties"] = false
+    if len(required) > 0 {
+        def["required"] = required
+    }
+
+    // At most one field of each oneof may be set: exactly one branch holds
+    // when one field is present or none is
+    var constraints []jsonSchema
+    for i := 0; i < md.Oneofs().Len(); i++ {
+        oneof := md.Oneofs().Get(i)
+        if oneof.IsSynthetic() {
+            continue
+        }
+        var branches, present []jsonSchema
+        for j := 0; j < oneof.Fields().Len(); j++ {
+            branch := fieldPresent(oneof.Fields().Get(j))
+            branches = append(branches, branch)
+            present = append(present, branch)
+        }
+        branches = append(branches, jsonSchema{"not": jsonSchema{"anyOf": present}})
+        constraints = append(constraints, jsonSchema{"oneOf": branches})
+    }
+    if len(constraints) > 0 {
+        def["allOf"] = constraints
+    }
+    return b.ref(name)
+}
+
+// enum returns the schema of an enum value, which protojson accepts as the
+// value name or its number.
+func (b *schemaBuilder) enum(ed protoreflect.EnumDescriptor) jsonSchema {
+    if ed.FullName() == "google.protobuf.NullValue" {
+        return jsonSchema{"type": "null"}
+    }
+    name := ed.FullName()
+    if _, ok := b.defs[string(name)]; ok {
+        return b.ref(name)
+    }
+
+    names := make([]string, 0, ed.Values().Len())
+    for i := 0; i < ed.Values().Len(); i++ {
+        names = append(names, string(ed.Values().Get(i).Name()))
+    }
+    def := jsonSchema{
+        "title": relativeName(ed),
+        "anyOf": []jsonSchema{
+            {"type": "string", "enum": names},
+            {"type": "integer", "minimum": math.MinInt32, "maximum": math.MaxInt32},
+        },
+    }
+    describe(def, ed)
+    b.defs[string(name)] = def
+    return b.ref(name)
+}
+
+// field returns the schema of a field's JSON value. Every field also accepts
+// null, which protojson treats as the default value.
+func (b *schemaBuilder) field(fd protoreflect.FieldDescriptor) jsonSchema {
+    switch {
+    case fd.IsMap():
+        keys := jsonSchema{"type": "string"}
+        switch fd.MapKey().Kind() {
+        case protoreflect.BoolKind:
+            keys["enum"] = []string{"true", "false"}
+        case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+            keys["pattern"] = uintPattern
+        case protoreflect.StringKind:
+        default:
+            keys["pattern"] = intPattern
+        }
+        return nullable(jsonSchema{"type": "object", "propertyNames": keys, "additionalProperties": b.singular(fd.MapValue())})
+    case fd.IsList():
+        return nullable(jsonSchema{"type": "array", "items": b.singular(fd)})
+    }
+    return nullable(b.singular(fd))
+}
+
+// singular returns the schema of one value of fd, ignoring cardinality.
+func (b *schemaBuilder) singular(fd protoreflect.FieldDescriptor) jsonSchema {
+    switch fd.Kind() {
+    case protoreflect.MessageKind, protoreflect.GroupKind:
+        return b.message(fd.Message())
+    case protoreflect.EnumKind:
+        return b.enum(fd.Enum())
+    }
+    return scalarJSONSchema(fd.Kind())
+}
+
+// wellKnown returns the special proto3 JSON form of the well-known types.
+func (b *schemaBuilder) wellKnown(md protoreflect.MessageDescriptor) (jsonSchema, bool) {
+    switch md.FullName() {
+    case "google.protobuf.Timestamp":
+        return jsonSchema{"type": "string", "format": "date-time"}, true
+    case "google.protobuf.Duration":
+        return jsonSchema{"type": "string", "pattern": durationPattern}, true
+    case "google.protobuf.FieldMask":
+        return jsonSchema{"type": "string"}, true
+    case "google.protobuf.Struct":
+        return jsonSchema{"type": "object"}, true
+    case "google.protobuf.ListValue":
+        return jsonSchema{"type": "array"}, true
+    case "google.protobuf.Value":
+        return jsonSchema{}, true
+    case "google.protobuf.Empty":
+        return jsonSchema{"type": "object", "additionalProperties": false}, true
+    case "google.protobuf.Any":
+        return jsonSchema{
+            "type":       "object",
+            "properties": jsonSchema{"@type": jsonSchema{"type": "string"}},
+            "required":   []string{"@type"},
+        }, true
+    case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+        "google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+        "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+        "google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+        // Wrappers are written as their wrapped value
+        if value := md.Fields().ByName("value"); value != nil {
+            return scalarJSONSchema(value.Kind()), true
+        }
+    }
+    return nil, false
+}
+
+// scalarJSONSchema returns the proto3 JSON form of a scalar kind. Integers
+// and floats are also accepted as strings; 64-bit integers are usually
+// written that way.
+func scalarJSONSchema(kind protoreflect.Kind) jsonSchema {
+    switch kind {
+    case protoreflect.BoolKind:
+        return jsonSchema{"type": "boolean"}
+    case protoreflect.StringKind:
+        return jsonSchema{"type": "string"}
+    case protoreflect.BytesKind:
+        return jsonSchema{"type": "string", "contentEncoding": "base64"}
+    case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+        return jsonSchema{"type": []string{"integer", "string"}, "pattern": intPattern, "minimum": math.MinInt32, "maximum": math.MaxInt32}
+    case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+        return jsonSchema{"type": []string{"integer", "string"}, "pattern": uintPattern, "minimum": 0, "maximum": uint32(math.MaxUint32)}
+    case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+        return jsonSchema{"type": []string{"integer", "string"}, "pattern": intPattern, "minimum": int64(math.MinInt64), "maximum": int64(math.MaxInt64)}
+    case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+        return jsonSchema{"type": []string{"integer", "string"}, "pattern": uintPattern, "minimum": 0, "maximum": uint64(math.MaxUint64)}
+    case protoreflect.FloatKind, protoreflect.DoubleKind:
+        return jsonSchema{"type": []string{"number", "string"}, "pattern": floatPattern}
+    }
+    return jsonSchema{}
+}
+
+// nullable allows null in addition to the values schema accepts.
+func nullable(schema jsonSchema) jsonSchema {
+    if len(schema) == 0 || schema["type"] == "null" {
+        return schema
+    }
+    _, constrained := schema["enum"]
+    switch t := schema["type"].(type) {
+    case string:
+        if !constrained {
+            schema["type"] = []string{t, "null"}
+            return schema
+        }
+    case []string:
+        if !constrained {
+            schema["type"] = append(t, "null")
+            return schema
+        }
+    }
+    return jsonSchema{"anyOf": []jsonSchema{schema, {"type": "null"}}}
+}
+
+// describe copies the comment and deprecation of d onto schema.
+func describe(schema jsonSchema, d protoreflect.Descriptor) {
+    if comment := descriptorComment(d); comment != "" {
+        schema["description"] = comment
+    }
+    if isDeprecated(d) {
+        schema["deprecated"] = true
+    }
+}
+
+// fieldJSONNames returns the property names protojson accepts for fd.
+func fieldJSONNames(fd protoreflect.FieldDescriptor) []string {
+    if fd.JSONName() == string(fd.Name()) {
+        return []string{fd.JSONName()}
+    }
+    return []string{fd.JSONName(), string(fd.Name())}
+}
+
+// fieldPresent matches objects that set fd under either of its names.
+func fieldPresent(fd protoreflect.FieldDescriptor) jsonSchema {
+    names := fieldJSONNames(fd)
+    if len(names) == 1 {
+        return jsonSchema{"required": names}
+    }
+    return jsonSchema{"anyOf": []jsonSchema{{"required": names[:1]}, {"required": names[1:]}}}
+}
@@ -0,0 +1,121 @@
+// protomanager/jsonschema_test.go
+package protomanager
+
+import (
+    "context"
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestMessageJSONSchema(t *testing.T) {
+    dir := t.TempDir()
+    file := filepath.Join(dir, "order.proto")
+    src := `syntax = "proto3";
+package shop.v1;
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/wrappers.proto";
+
+// Order is an order.
+message Order {
+  string order_id = 1;
+  int64 total_cents = 2;
+  Status status = 3;
+  repeated Item items = 4;
+  map<string, int32> counts = 5;
+  google.protobuf.Timestamp created_at = 6;
+  google.protobuf.StringValue note = 7;
+  Order parent = 8;
+  oneof payment {
+    string card = 9;
+    string voucher = 10;
+  }
+  string legacy = 11 [deprecated = true];
+}
+
+// Item is a line item.
+message Item { string sku = 1; }
+
+// Status is an order status.
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_PAID = 1;
+}
+`
+    if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+        t.Fatal(err)
+    }
+    _, compiled, err := compileProtos(context.Background(), []string{dir}, []string{file})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // Compare through JSON, the form schemas are written in.
+    data, err := json.Marshal(messageJSONSchema(compiled[0].Messages().ByName("Order")))
+    if err != nil {
+        t.Fatal(err)
+    }
+    var schema map[string]interface{}
+    if err := json.Unmarshal(data, &schema); err != nil {
+        t.Fatal(err)
+    }
+    get := func(path ...string) interface{} {
+        var v interface{} = schema
+        for _, key := range path {
+            m, ok := v.(map[string]interface{})
+            if !ok {
+                return nil
+            }
+            v = m[key]
+        }
+        return v
+    }
+
+    tests := []struct {
+        path []string
+        want interface{}
+    }{
+        {[]string{"$schema"}, jsonSchemaDraft},
+        {[]string{"$id"}, "shop.v1.Order" + jsonSchemaExt},
+        {[]string{"$ref"}, "#/$defs/shop.v1.Order"},
+        {[]string{"$defs", "shop.v1.Order", "description"}, "Order is an order."},
+        {[]string{"$defs", "shop.v1.Order", "additionalProperties"}, false},
+        {[]string{"$defs", "shop.v1.Order", "properties", "orderId", "type"}, []interface{}{"string", "null"}},
+        {[]string{"$defs", "shop.v1.Order", "properties", "order_id", "type"}, []interface{}{"string", "null"}},
+        {[]string{"$defs", "shop.v1.Order", "properties", "totalCents", "pattern"}, intPattern},
+        {[]string{"$defs", "shop.v1.Order", "properties", "createdAt", "format"}, "date-time"},
+        {[]string{"$defs", "shop.v1.Order", "properties", "note", "type"}, []interface{}{"string", "null"}},
+        {[]string{"$defs", "shop.v1.Order", "properties", "parent", "anyOf"}, []interface{}{
+            map[string]interface{}{"$ref": "#/$defs/shop.v1.Order"},
+            map[string]interface{}{"type": "null"},
+        }},
+        {[]string{"$defs", "shop.v1.Order", "properties", "items", "items", "$ref"}, "#/$defs/shop.v1.Item"},
+        {[]string{"$defs", "shop.v1.Order", "properties", "counts", "additionalProperties", "pattern"}, intPattern},
+        {[]string{"$defs", "shop.v1.Order", "properties", "legacy", "deprecated"}, true},
+        {[]string{"$defs", "shop.v1.Item", "properties", "sku", "type"}, []interface{}{"string", "null"}},
+        {[]string{"$defs", "shop.v1.Status", "anyOf"}, []interface{}{
+            map[string]interface{}{"type": "string", "enum": []interface{}{"STATUS_UNSPECIFIED", "STATUS_PAID"}},
+            map[string]interface{}{"type": "integer", "minimum": float64(-2147483648), "maximum": float64(2147483647)},
+        }},
+        {[]string{"$defs", "shop.v1.Order", "allOf"}, []interface{}{
+            map[string]interface{}{"oneOf": []interface{}{
+                map[string]interface{}{"required": []interface{}{"card"}},
+                map[string]interface{}{"required": []interface{}{"voucher"}},
+                map[string]interface{}{"not": map[string]interface{}{"anyOf": []interface{}{
+                    map[string]interface{}{"required": []interface{}{"card"}},
+                    map[string]interface{}{"required": []interface{}{"voucher"}},
+                }}},
+            }},
+        }},
+    }
+    for _, tt := range tests {
+        if got := get(tt.path...); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%v = %#v, want %#v", tt.path, got, tt.want)
+        }
+    }
+    if defs, _ := get("$defs").(map[string]interface{}); len(defs) != 3 {
+        t.Errorf("$defs has %d definitions, want Order, Item and Status", len(defs))
+    }
+}
@@ -0,0 +1,115 @@
+// protomanager/languages.go
+package protomanager
+
+import (
+    "fmt"
+    "path/filepath"
+)
+
+// LanguageConfig describes how code is generated for one target language.
+type LanguageConfig struct {
+    // OutputDir is the subdirectory of a package's output directory the
+    // language is generated into. It defaults to the language name.
+    OutputDir string `yaml:"output_dir"`
+    // Plugins are run in order over the package's proto files.
+    Plugins []PluginConfig `yaml:"plugins"`
+    // PostGenerate hooks run in the output directory after the plugins.
+    PostGenerate []HookConfig `yaml:"post_generate"`
+    // HTTPRules maps methods without a google.api.http option with the
+    // default HTTP rule before the plugins run; see HTTPConfig.
+    HTTPRules bool `yaml:"http_rules"`
+}
+
+// PluginConfig configures one code generator plugin of a language.
+type PluginConfig struct {
+    Name      string   `yaml:"name"`       // Selects --<name>_out and protoc-gen-<name>
+    Binary    string   `yaml:"binary"`     // Plugin executable; empty uses protoc-gen-<name> from PATH
+    Options   []string `yaml:"options"`    // Plugin parameters, e.g. paths=source_relative
+    OutputDir string   `yaml:"output_dir"` // Subdirectory of the language output directory
+}
+
+// goLanguages are the languages whose output is Go code, sharing a go.mod.
+var goLanguages = map[string]bool{"go": true, "gateway": true}
+
+// defaultLanguages are the built-in language mappings. Entries in
+// Config.Languages replace them per language. "gateway" generates the Go
+// code together with its grpc-gateway reverse proxy, which must live in the
+// same Go package, so it is selected instead of "go".
+var defaultLanguages = map[string]LanguageConfig{
+    "go": {Plugins: []PluginConfig{
+        {Name: "go"},
+        {Name: "go-grpc"},
+    }},
+    "gateway": {OutputDir: "go", HTTPRules: true, Plugins: []PluginConfig{
+        {Name: "go"},
+        {Name: "go-grpc"},
+        {Name: "grpc-gateway"},
+    }},
+    "openapiv2": {HTTPRules: true, Plugins: []PluginConfig{
+        {Name: "openapiv2", Options: []string{"allow_merge=true"}},
+    }},
+    "openapiv3": {HTTPRules: true, Plugins: []PluginConfig{
+        {Name: "openapi"},
+    }},
+    "python": {Plugins: []PluginConfig{
+        {Name: "python"},
+        {Name: "pyi"},
+        {Name: "grpc_python", Binary: "grpc_python_plugin"},
+    }},
+    "java": {Plugins: []PluginConfig{
+        {Name: "java"},
+        {Name: "grpc-java"},
+    }},
+    "typescript": {Plugins: []PluginConfig{
+        {Name: "ts", Options: []string{"generate_dependencies"}},
+    }},
+    "csharp": {Plugins: []PluginConfig{
+        {Name: "csharp"},
+        {Name: "grpc_csharp", Binary: "grpc_csharp_plugin"},
+    }},
+    "cpp": {Plugins: []PluginConfig{
+        {Name: "cpp"},
+        {Name: "grpc_cpp", Binary: "grpc_cpp_plugin"},
+    }},
+}
+
+// Language returns the configuration of lang for a service: the per-service
+// override when one exists, then Config.Languages, then the built-in mapping.
+// serviceName may be empty for the global proto file.
+func (pm *ProtoManager) Language(serviceName, lang string) (LanguageConfig, error) {
+    if svc, ok := pm.Config.Services[serviceName]; ok {
+        if language, ok := svc.Languages[lang]; ok {
+            return language, nil
+        }
+    }
+    if language, ok := pm.Config.Languages[lang]; ok {
+        return language, nil
+    }
+    if language, ok := defaultLanguages[lang]; ok {
+        return language, nil
+    }
+    return LanguageConfig{}, fmt.Errorf("unknown language '%s'; add it under `languages` in the config file", lang)
+}
+
+// LanguageOutputDir returns the directory code for lang is generated into for a service.
+func (pm *ProtoManager) LanguageOutputDir(serviceName string, language LanguageConfig, lang string) string {
+    dir := language.OutputDir
+    if dir == "" {
+        dir = lang
+    }
+    return filepath.Join(pm.OutputDir, serviceName, dir)
+}
+
+// PluginInvocations returns the plugin runs of language writing below outDir.
+func (language LanguageConfig) PluginInvocations(outDir string) []PluginInvocation {
+    invocations := make([]PluginInvocation, 0, len(language.Plugins))
+    for _, plugin := range language.Plugins {
+        invocations = append(invocations, PluginInvocation{
+            Name:    plugin.Name,
+            Binary:  plugin.Binary,
+            Options: plugin.Options,
+            OutDir:  filepath.Join(outDir, plugin.OutputDir),
+        })
+    }
+    return invocations
+}
@@ -0,0 +1,52 @@
+// protomanager/languages_test.go
+package protomanager
+
+import (
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestLanguage(t *testing.T) {
+    pm := newTestProtoManager(t)
+    custom := LanguageConfig{OutputDir: "golang", Plugins: []PluginConfig{{Name: "go", Options: []string{"paths=source_relative"}}}}
+    vtproto := LanguageConfig{Plugins: []PluginConfig{{Name: "go"}, {Name: "go-vtproto", Binary: "/opt/bin/protoc-gen-go-vtproto"}}}
+    pm.Config.Languages = map[string]LanguageConfig{"go": custom}
+    pm.Config.Services = map[string]ServiceConfig{"billing": {Languages: map[string]LanguageConfig{"go": vtproto}}}
+
+    tests := []struct {
+        name, service, lang string
+        want                LanguageConfig
+        wantErr             bool
+    }{
+        {name: "service override replaces the built-in mapping", service: "billing", lang: "go", want: vtproto},
+        {name: "config override for other services", service: "orders", lang: "go", want: custom},
+        {name: "config override for the global proto file", lang: "go", want: custom},
+        {name: "built-in mapping", service: "billing", lang: "python", want: defaultLanguages["python"]},
+        {name: "unknown language", service: "billing", lang: "cobol", wantErr: true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := pm.Language(tt.service, tt.lang)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("Language() error = %v, wantErr %t", err, tt.wantErr)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("Language() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+
+    outDir := pm.LanguageOutputDir("billing", custom, "go")
+    if want := filepath.Join(pm.OutputDir, "billing", "golang"); outDir != want {
+        t.Errorf("LanguageOutputDir() = %s, want %s", outDir, want)
+    }
+    invocations := vtproto.PluginInvocations(outDir)
+    want := []PluginInvocation{
+        {Name: "go", OutDir: outDir},
+        {Name: "go-vtproto", Binary: "/opt/bin/protoc-gen-go-vtproto", OutDir: outDir},
+    }
+    if !reflect.DeepEqual(invocations, want) {
+        t.Errorf("PluginInvocations() = %+v, want %+v", invocations, want)
+    }
+}
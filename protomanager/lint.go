@@ -0,0 +1,490 @@
+// protomanager/lint.go
+package protomanager
+
+import (
+    "context"
+    "fmt"
+    "path"
+    "regexp"
+    "sort"
+    "strings"
+
+    "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Lint severities. LintSeverityOff disables a rule in LintConfig.Rules.
+const (
+    LintSeverityError   = "error"
+    LintSeverityWarning = "warning"
+    LintSeverityOff     = "off"
+)
+
+// lintIgnoreDirective in a comment suppresses the named rule for the
+// commented declaration and everything nested in it.
+const lintIgnoreDirective = "protomanager:lint:ignore"
+
+var (
+    pascalCasePattern     = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+    lowerSnakeCasePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+    upperSnakeCasePattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+    lintPackagePattern    = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*$`)
+)
+
+// LintConfig configures the lint rules run by `protomanager lint` and the
+// validation task.
+type LintConfig struct {
+    // Rules overrides the severity of rules by ID: error, warning or off.
+    Rules map[string]string `yaml:"rules"`
+}
+
+// LintRule is a check run by Lint.
+type LintRule struct {
+    ID          string
+    Severity    string // Default severity
+    Description string
+    check       func(files []protoreflect.FileDescriptor, report lintReporter)
+}
+
+// lintReporter records a finding located at d.
+type lintReporter func(d protoreflect.Descriptor, format string, args ...interface{})
+
+// LintFinding is a rule violation found by Lint.
+type LintFinding struct {
+    Rule     string `json:"rule"`
+    Severity string `json:"severity"`
+    File     string `json:"file"`
+    Line     int    `json:"line,omitempty"`
+    Column   int    `json:"column,omitempty"`
+    Message  string `json:"message"`
+}
+
+// String implements fmt.Stringer.
+func (f LintFinding) String() string {
+    location := f.File
+    if f.Line > 0 {
+        location = fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+    }
+    return fmt.Sprintf("%s: %s: %s (%s)", location, f.Severity, f.Message, f.Rule)
+}
+
+// Diagnostic returns the finding as a Diagnostic.
+func (f LintFinding) Diagnostic() Diagnostic {
+    return Diagnostic{
+        Source:   "lint/" + f.Rule,
+        File:     f.File,
+        Line:     f.Line,
+        Column:   f.Column,
+        Severity: f.Severity,
+        Message:  f.Message,
+    }
+}
+
+// LintError reports lint findings of error severity.
+type LintError struct {
+    Findings []LintFinding // Every finding, including warnings
+}
+
+// Error implements the error interface.
+func (e *LintError) Error() string {
+    var lines []string
+    for _, f := range e.Findings {
+        if f.Severity == LintSeverityError {
+            lines = append(lines, "  "+f.String())
+        }
+    }
+    return fmt.Sprintf("lint found %d error(s)\n%s", len(lines), strings.Join(lines, "\n"))
+}
+
+// LintRules returns every lint rule with its default severity, sorted by ID.
+func LintRules() []LintRule {
+    rules := append([]LintRule{}, lintRules...)
+    sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+    return rules
+}
+
+// lintRules is the rule set run by Lint.
+var lintRules = []LintRule{
+    {ID: "PACKAGE_DEFINED", Severity: LintSeverityError, Description: "Files declare a package", check: lintPackageDefined},
+    {ID: "PACKAGE_LOWER_SNAKE_CASE", Severity: LintSeverityError, Description: "Packages are dot-separated lower_snake_case", check: lintPackageCase},
+    {ID: "PACKAGE_DIRECTORY_MATCH", Severity: LintSeverityWarning, Description: "Files below the include path root live in the directory matching their package, e.g. pay/v1 for pay.v1", check: lintPackageDirectory},
+    {ID: "PACKAGE_SAME_DIRECTORY", Severity: LintSeverityError, Description: "Files in the same directory declare the same package", check: lintPackageSameDirectory},
+    {ID: "SERVICE_PASCAL_CASE", Severity: LintSeverityError, Description: "Services are PascalCase", check: lintServiceCase},
+    {ID: "RPC_PASCAL_CASE", Severity: LintSeverityError, Description: "RPCs are PascalCase", check: lintRPCCase},
+    {ID: "MESSAGE_PASCAL_CASE", Severity: LintSeverityError, Description: "Messages are PascalCase", check: lintMessageCase},
+    {ID: "FIELD_LOWER_SNAKE_CASE", Severity: LintSeverityError, Description: "Fields are lower_snake_case", check: lintFieldCase},
+    {ID: "ONEOF_LOWER_SNAKE_CASE", Severity: LintSeverityError, Description: "Oneofs are lower_snake_case", check: lintOneofCase},
+    {ID: "ENUM_PASCAL_CASE", Severity: LintSeverityError, Description: "Enums are PascalCase", check: lintEnumCase},
+    {ID: "ENUM_VALUE_UPPER_SNAKE_CASE", Severity: LintSeverityError, Description: "Enum values are UPPER_SNAKE_CASE", check: lintEnumValueCase},
+    {ID: "ENUM_VALUE_PREFIX", Severity: LintSeverityWarning, Description: "Enum values are prefixed with the UPPER_SNAKE_CASE enum name", check: lintEnumValuePrefix},
+    {ID: "ENUM_ZERO_VALUE_SUFFIX", Severity: LintSeverityError, Description: "The zero value of an enum ends in _UNSPECIFIED", check: lintEnumZeroValue},
+    {ID: "COMMENT_SERVICE", Severity: LintSeverityWarning, Description: "Services have a comment", check: lintCommentServices},
+    {ID: "COMMENT_RPC", Severity: LintSeverityWarning, Description: "RPCs have a comment", check: lintCommentRPCs},
+    {ID: "COMMENT_MESSAGE", Severity: LintSeverityWarning, Description: "Messages have a comment", check: lintCommentMessages},
+    {ID: "COMMENT_ENUM", Severity: LintSeverityWarning, Description: "Enums have a comment", check: lintCommentEnums},
+    {ID: "RPC_REQUEST_RESPONSE_UNIQUE", Severity: LintSeverityWarning, Description: "Each message is the request or response of at most one RPC", check: lintRequestResponseUnique},
+}
+
+// LintPackage lints the proto files of a registered service, or the global
+// proto file when packageName is "global".
+func (pm *ProtoManager) LintPackage(ctx context.Context, packageName string) ([]LintFinding, error) {
+    if packageName == globalOwner {
+        return pm.Lint(ctx, pm.IncludePaths(), []string{pm.GlobalProtoPath})
+    }
+    files, err := pm.DiscoverProtos(packageName)
+    if err != nil {
+        return nil, err
+    }
+    return pm.Lint(ctx, pm.IncludePaths(pm.ProtoPath(packageName)), files)
+}
+
+// Lint compiles files in process and checks them against the lint rules,
+// with the severities of Config.Lint. Imported files are not linted. The
+// findings are sorted by location; a file that does not compile fails with
+// a *GenerateError instead.
+func (pm *ProtoManager) Lint(ctx context.Context, includePaths, files []string) ([]LintFinding, error) {
+    severities, err := pm.lintSeverities()
+    if err != nil {
+        return nil, err
+    }
+    names, compiled, err := compileProtos(ctx, includePaths, files)
+    if err != nil {
+        return nil, err
+    }
+    paths := make(map[string]string, len(names))
+    for i, name := range names {
+        paths[name] = files[i]
+    }
+
+    var findings []LintFinding
+    for _, rule := range lintRules {
+        severity := severities[rule.ID]
+        if severity == LintSeverityOff {
+            continue
+        }
+        rule.check(compiled, func(d protoreflect.Descriptor, format string, args ...interface{}) {
+            if lintIgnored(d, rule.ID) {
+                return
+            }
+            file := d.ParentFile()
+            finding := LintFinding{
+                Rule:     rule.ID,
+                Severity: severity,
+                File:     paths[file.Path()],
+                Message:  fmt.Sprintf(format, args...),
+            }
+            loc := file.SourceLocations().ByDescriptor(d)
+            if _, ok := d.(protoreflect.FileDescriptor); ok {
+                loc = file.SourceLocations().ByPath(protoreflect.SourcePath{2}) // The package statement
+            }
+            if loc.Path != nil {
+                finding.Line, finding.Column = loc.StartLine+1, loc.StartColumn+1
+            }
+            findings = append(findings, finding)
+        })
+    }
+    sort.SliceStable(findings, func(i, j int) bool {
+        a, b := findings[i], findings[j]
+        if a.File != b.File {
+            return a.File < b.File
+        }
+        if a.Line != b.Line {
+            return a.Line < b.Line
+        }
+        return a.Column < b.Column
+    })
+
+    var errs, warnings int
+    for _, f := range findings {
+        if f.Severity == LintSeverityError {
+            errs++
+        } else {
+            warnings++
+        }
+    }
+    pm.Logger.Infof("Linted %d file(s): %d error(s), %d warning(s)", len(files), errs, warnings)
+    pm.emitEvent(Event{Type: "ProtosLinted", Message: fmt.Sprintf("Linted %d file(s): %d error(s), %d warning(s)", len(files), errs, warnings)})
+    return findings, nil
+}
+
+// lintSeverities returns the severity of every rule after Config.Lint overrides.
+func (pm *ProtoManager) lintSeverities() (map[string]string, error) {
+    severities := make(map[string]string, len(lintRules))
+    for _, rule := range lintRules {
+        severities[rule.ID] = rule.Severity
+    }
+    for _, id := range sortedKeys(pm.Config.Lint.Rules) {
+        severity := pm.Config.Lint.Rules[id]
+        if _, ok := severities[id]; !ok {
+            return nil, fmt.Errorf("unknown lint rule '%s'", id)
+        }
+        if severity != LintSeverityError && severity != LintSeverityWarning && severity != LintSeverityOff {
+            return nil, fmt.Errorf("invalid severity '%s' for lint rule '%s'; use error, warning or off", severity, id)
+        }
+        severities[id] = severity
+    }
+    return severities, nil
+}
+
+// LintErrors returns a *LintError when findings include errors, otherwise nil.
+func LintErrors(findings []LintFinding) error {
+    for _, f := range findings {
+        if f.Severity == LintSeverityError {
+            return &LintError{Findings: findings}
+        }
+    }
+    return nil
+}
+
+// lintIgnored reports whether a comment on d or a declaration enclosing it
+// ignores rule.
+func lintIgnored(d protoreflect.Descriptor, rule string) bool {
+    for ; d != nil; d = d.Parent() {
+        locations := d.ParentFile().SourceLocations()
+        loc := locations.ByDescriptor(d)
+        if _, ok := d.(protoreflect.FileDescriptor); ok {
+            loc = locations.ByPath(protoreflect.SourcePath{2})
+        }
+        for _, line := range strings.Split(loc.LeadingComments, "\n") {
+            fields := strings.Fields(line)
+            if len(fields) >= 2 && fields[0] == lintIgnoreDirective {
+                for _, id := range fields[1:] {
+                    if id == rule {
+                        return true
+                    }
+                }
+            }
+        }
+    }
+    return false
+}
+
+// eachService calls fn for every service of files.
+func eachService(files []protoreflect.FileDescriptor, fn func(protoreflect.ServiceDescriptor)) {
+    for _, file := range files {
+        for i := 0; i < file.Services().Len(); i++ {
+            fn(file.Services().Get(i))
+        }
+    }
+}
+
+// eachMethod calls fn for every RPC of files.
+func eachMethod(files []protoreflect.FileDescriptor, fn func(protoreflect.MethodDescriptor)) {
+    eachService(files, func(service protoreflect.ServiceDescriptor) {
+        for i := 0; i < service.Methods().Len(); i++ {
+            fn(service.Methods().Get(i))
+        }
+    })
+}
+
+// eachMessage calls fn for every message of files, including nested ones
+// but not map entries.
+func eachMessage(files []protoreflect.FileDescriptor, fn func(protoreflect.MessageDescriptor)) {
+    eachType(files, func(d protoreflect.Descriptor) {
+        if message, ok := d.(protoreflect.MessageDescriptor); ok {
+            fn(message)
+        }
+    })
+}
+
+// eachEnum calls fn for every enum of files, including nested ones.
+func eachEnum(files []protoreflect.FileDescriptor, fn func(protoreflect.EnumDescriptor)) {
+    eachType(files, func(d protoreflect.Descriptor) {
+        if enum, ok := d.(protoreflect.EnumDescriptor); ok {
+            fn(enum)
+        }
+    })
+}
+
+// eachType calls fn for every message and enum of files.
+func eachType(files []protoreflect.FileDescriptor, fn func(protoreflect.Descriptor)) {
+    for _, file := range files {
+        for _, decl := range topLevelDeclarations(file) {
+            if _, ok := decl.(protoreflect.ServiceDescriptor); !ok {
+                walkTypes(decl, fn)
+            }
+        }
+    }
+}
+
+func lintPackageDefined(files []protoreflect.FileDescriptor, report lintReporter) {
+    for _, file := range files {
+        if file.Package() == "" {
+            report(file, "file '%s' does not declare a package", file.Path())
+        }
+    }
+}
+
+func lintPackageCase(files []protoreflect.FileDescriptor, report lintReporter) {
+    for _, file := range files {
+        if pkg := string(file.Package()); pkg != "" && !lintPackagePattern.MatchString(pkg) {
+            report(file, "package '%s' should be dot-separated lower_snake_case", pkg)
+        }
+    }
+}
+
+func lintPackageDirectory(files []protoreflect.FileDescriptor, report lintReporter) {
+    for _, file := range files {
+        pkg := string(file.Package())
+        dir := path.Dir(file.Path())
+        // Files at the root of their include path, such as the global proto
+        // file and those of a flat service directory, have no directory to match.
+        if pkg == "" || dir == "." {
+            continue
+        }
+        if want := strings.ReplaceAll(pkg, ".", "/"); dir != want {
+            report(file, "package '%s' should be in directory '%s', not '%s'", pkg, want, dir)
+        }
+    }
+}
+
+func lintPackageSameDirectory(files []protoreflect.FileDescriptor, report lintReporter) {
+    byDir := map[string][]protoreflect.FileDescriptor{}
+    for _, file := range files {
+        dir := path.Dir(file.Path())
+        byDir[dir] = append(byDir[dir], file)
+    }
+    for _, dir := range sortedKeys(byDir) {
+        group := byDir[dir]
+        sort.Slice(group, func(i, j int) bool { return group[i].Path() < group[j].Path() })
+        for _, file := range group[1:] {
+            if file.Package() != group[0].Package() {
+                report(file, "package '%s' differs from '%s' declared by '%s' in the same directory", file.Package(), group[0].Package(), group[0].Path())
+            }
+        }
+    }
+}
+
+func lintServiceCase(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachService(files, func(service protoreflect.ServiceDescriptor) {
+        if !pascalCasePattern.MatchString(string(service.Name())) {
+            report(service, "service '%s' should be PascalCase, e.g. '%s'", service.Name(), pascalCase(string(service.Name())))
+        }
+    })
+}
+
+func lintRPCCase(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachMethod(files, func(method protoreflect.MethodDescriptor) {
+        if !pascalCasePattern.MatchString(string(method.Name())) {
+            report(method, "RPC '%s' should be PascalCase, e.g. '%s'", method.Name(), pascalCase(string(method.Name())))
+        }
+    })
+}
+
+func lintMessageCase(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachMessage(files, func(message protoreflect.MessageDescriptor) {
+        if !pascalCasePattern.MatchString(string(message.Name())) {
+            report(message, "message '%s' should be PascalCase, e.g. '%s'", message.Name(), pascalCase(string(message.Name())))
+        }
+    })
+}
+
+func lintFieldCase(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachMessage(files, func(message protoreflect.MessageDescriptor) {
+        for i := 0; i < message.Fields().Len(); i++ {
+            field := message.Fields().Get(i)
+            if !lowerSnakeCasePattern.MatchString(string(field.Name())) {
+                report(field, "field '%s' of '%s' should be lower_snake_case, e.g. '%s'", field.Name(), message.Name(), snakeCase(string(field.Name())))
+            }
+        }
+    })
+}
+
+func lintOneofCase(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachMessage(files, func(message protoreflect.MessageDescriptor) {
+        for i := 0; i < message.Oneofs().Len(); i++ {
+            oneof := message.Oneofs().Get(i)
+            if !oneof.IsSynthetic() && !lowerSnakeCasePattern.MatchString(string(oneof.Name())) {
+                report(oneof, "oneof '%s' of '%s' should be lower_snake_case, e.g. '%s'", oneof.Name(), message.Name(), snakeCase(string(oneof.Name())))
+            }
+        }
+    })
+}
+
+func lintEnumCase(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachEnum(files, func(enum protoreflect.EnumDescriptor) {
+        if !pascalCasePattern.MatchString(string(enum.Name())) {
+            report(enum, "enum '%s' should be PascalCase, e.g. '%s'", enum.Name(), pascalCase(string(enum.Name())))
+        }
+    })
+}
+
+func lintEnumValueCase(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachEnum(files, func(enum protoreflect.EnumDescriptor) {
+        for i := 0; i < enum.Values().Len(); i++ {
+            value := enum.Values().Get(i)
+            if !upperSnakeCasePattern.MatchString(string(value.Name())) {
+                report(value, "enum value '%s' should be UPPER_SNAKE_CASE, e.g. '%s'", value.Name(), strings.ToUpper(snakeCase(string(value.Name()))))
+            }
+        }
+    })
+}
+
+func lintEnumValuePrefix(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachEnum(files, func(enum protoreflect.EnumDescriptor) {
+        prefix := strings.ToUpper(snakeCase(string(enum.Name()))) + "_"
+        for i := 0; i < enum.Values().Len(); i++ {
+            value := enum.Values().Get(i)
+            if !strings.HasPrefix(string(value.Name()), prefix) {
+                report(value, "enum value '%s' should be prefixed with '%s'", value.Name(), prefix)
+            }
+        }
+    })
+}
+
+func lintEnumZeroValue(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachEnum(files, func(enum protoreflect.EnumDescriptor) {
+        want := strings.ToUpper(snakeCase(string(enum.Name()))) + "_UNSPECIFIED"
+        value := enum.Values().ByNumber(0)
+        switch {
+        case value == nil:
+            report(enum, "enum '%s' has no zero value; add '%s = 0' as its first value", enum.Name(), want)
+        case !strings.HasSuffix(string(value.Name()), "_UNSPECIFIED"):
+            report(value, "zero value '%s' of enum '%s' should be named '%s'", value.Name(), enum.Name(), want)
+        }
+    })
+}
+
+// lintComment reports d when it has no comment of its own.
+func lintComment(kind string, d protoreflect.Descriptor, report lintReporter) {
+    if descriptorComment(d) == "" {
+        report(d, "%s '%s' has no comment", kind, d.Name())
+    }
+}
+
+func lintCommentServices(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachService(files, func(service protoreflect.ServiceDescriptor) { lintComment("service", service, report) })
+}
+
+func lintCommentRPCs(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachMethod(files, func(method protoreflect.MethodDescriptor) { lintComment("RPC", method, report) })
+}
+
+func lintCommentMessages(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachMessage(files, func(message protoreflect.MessageDescriptor) { lintComment("message", message, report) })
+}
+
+func lintCommentEnums(files []protoreflect.FileDescriptor, report lintReporter) {
+    eachEnum(files, func(enum protoreflect.EnumDescriptor) { lintComment("enum", enum, report) })
+}
+
+func lintRequestResponseUnique(files []protoreflect.FileDescriptor, report lintReporter) {
+    type use struct {
+        method protoreflect.MethodDescriptor
+        role   string
+    }
+    uses := map[protoreflect.FullName]use{}
+    eachMethod(files, func(method protoreflect.MethodDescriptor) {
+        for _, u := range []struct {
+            role    string
+            message protoreflect.MessageDescriptor
+        }{{"request", method.Input()}, {"response", method.Output()}} {
+            first, ok := uses[u.message.FullName()]
+            if !ok {
+                uses[u.message.FullName()] = use{method: method, role: u.role}
+                continue
+            }
+            report(method, "%s '%s' of RPC '%s' is already the %s of '%s'; give each RPC its own request and response messages",
+                u.role, u.message.FullName(), method.Name(), first.role, first.method.FullName())
+        }
+    })
+}
@@ -0,0 +1,219 @@
+// protomanager/lint_test.go
+package protomanager
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// TestBuiltinTemplatesLintClean lints the global proto file holding two
+// services scaffolded from every built-in template.
+func TestBuiltinTemplatesLintClean(t *testing.T) {
+    pm := newTestProtoManager(t)
+    for _, name := range pm.TemplateNames() {
+        t.Run(name, func(t *testing.T) {
+            writeGlobalProto(t, pm, name, "myservice", "order-history")
+            findings, err := pm.LintPackage(context.Background(), globalOwner)
+            if err != nil {
+                t.Fatal(err)
+            }
+            for _, f := range findings {
+                t.Errorf("unexpected finding: %s", f)
+            }
+        })
+    }
+}
+
+func TestLintRules(t *testing.T) {
+    tests := []struct {
+        name  string
+        path  string // Relative to the include path; defaults to pay/v1/x.proto
+        src   string
+        rules []string // Rules expected to fire, once each
+    }{
+        {
+            name: "clean",
+            src: `syntax = "proto3";
+package pay.v1;
+// Billing bills.
+service Billing {
+  // Charge charges.
+  rpc Charge(ChargeRequest) returns (ChargeResponse);
+}
+// ChargeRequest is a request.
+message ChargeRequest { string account_id = 1; }
+// ChargeResponse is a response.
+message ChargeResponse {
+  // State is a state.
+  enum State {
+    STATE_UNSPECIFIED = 0;
+    STATE_DONE = 1;
+  }
+  State state = 1;
+  oneof result { string receipt = 2; }
+}`,
+        },
+        {
+            name: "no package",
+            path: "x.proto",
+            src: `syntax = "proto3";
+// Msg is a message.
+message Msg {}`,
+            rules: []string{"PACKAGE_DEFINED"},
+        },
+        {
+            name:  "package case",
+            path:  "x.proto",
+            src:   `syntax = "proto3"; package Pay.V1;`,
+            rules: []string{"PACKAGE_LOWER_SNAKE_CASE"},
+        },
+        {
+            name:  "package directory",
+            path:  "billing/x.proto",
+            src:   `syntax = "proto3"; package pay.v1;`,
+            rules: []string{"PACKAGE_DIRECTORY_MATCH"},
+        },
+        {
+            name: "flat root is exempt from directory match",
+            path: "x.proto",
+            src:  `syntax = "proto3"; package pay.v1;`,
+        },
+        {
+            name: "naming",
+            src: `syntax = "proto3";
+package pay.v1;
+// billing_service bills.
+service billing_service {
+  // charge charges.
+  rpc charge(charge_request) returns (Resp);
+}
+// charge_request is a request.
+message charge_request {
+  string AccountId = 1;
+  oneof Result { string receipt = 2; }
+}
+// Resp is a response.
+message Resp {}
+// state is a state.
+enum state {
+  STATE_UNSPECIFIED = 0;
+  StateDone = 1;
+}`,
+            rules: []string{"SERVICE_PASCAL_CASE", "RPC_PASCAL_CASE", "MESSAGE_PASCAL_CASE", "FIELD_LOWER_SNAKE_CASE", "ONEOF_LOWER_SNAKE_CASE", "ENUM_PASCAL_CASE", "ENUM_VALUE_UPPER_SNAKE_CASE", "ENUM_VALUE_PREFIX"},
+        },
+        {
+            name: "enum zero value",
+            src: `syntax = "proto3";
+package pay.v1;
+// Color is a color.
+enum Color {
+  COLOR_RED = 0;
+}`,
+            rules: []string{"ENUM_ZERO_VALUE_SUFFIX"},
+        },
+        {
+            name: "comments",
+            src: `syntax = "proto3";
+package pay.v1;
+service Billing {
+  rpc Charge(Req) returns (Resp);
+}
+message Req {}
+message Resp {}
+enum Color { COLOR_UNSPECIFIED = 0; }`,
+            rules: []string{"COMMENT_SERVICE", "COMMENT_RPC", "COMMENT_MESSAGE", "COMMENT_MESSAGE", "COMMENT_ENUM"},
+        },
+        {
+            name: "shared request",
+            src: `syntax = "proto3";
+package pay.v1;
+// Billing bills.
+service Billing {
+  // A is an RPC.
+  rpc A(Req) returns (Resp);
+  // B is an RPC.
+  rpc B(Req) returns (Other);
+}
+// Req is a request.
+message Req {}
+// Resp is a response.
+message Resp {}
+// Other is a response.
+message Other {}`,
+            rules: []string{"RPC_REQUEST_RESPONSE_UNIQUE"},
+        },
+        {
+            name: "ignore directive",
+            src: `syntax = "proto3";
+package pay.v1;
+// legacy_msg predates the style guide.
+// protomanager:lint:ignore MESSAGE_PASCAL_CASE FIELD_LOWER_SNAKE_CASE
+message legacy_msg { string BadName = 1; }`,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            pm := newTestProtoManager(t)
+            root := t.TempDir()
+            rel := tt.path
+            if rel == "" {
+                rel = "pay/v1/x.proto"
+            }
+            file := filepath.Join(root, filepath.FromSlash(rel))
+            if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+                t.Fatal(err)
+            }
+            if err := os.WriteFile(file, []byte(tt.src), 0644); err != nil {
+                t.Fatal(err)
+            }
+
+            findings, err := pm.Lint(context.Background(), []string{root}, []string{file})
+            if err != nil {
+                t.Fatal(err)
+            }
+            got := map[string]int{}
+            for _, f := range findings {
+                got[f.Rule]++
+                if f.File != file {
+                    t.Errorf("finding %s reported for file %q", f, f.File)
+                }
+            }
+            want := map[string]int{}
+            for _, rule := range tt.rules {
+                want[rule]++
+            }
+            for _, rule := range sortedKeys(got) {
+                if got[rule] != want[rule] {
+                    t.Errorf("%s fired %d time(s), want %d", rule, got[rule], want[rule])
+                }
+            }
+            for _, rule := range sortedKeys(want) {
+                if got[rule] == 0 {
+                    t.Errorf("%s did not fire", rule)
+                }
+            }
+        })
+    }
+}
+
+func TestLintSeverities(t *testing.T) {
+    pm := newTestProtoManager(t)
+    pm.Config.Lint.Rules = map[string]string{"COMMENT_MESSAGE": LintSeverityOff, "ENUM_VALUE_PREFIX": LintSeverityError}
+    severities, err := pm.lintSeverities()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if severities["COMMENT_MESSAGE"] != LintSeverityOff || severities["ENUM_VALUE_PREFIX"] != LintSeverityError || severities["SERVICE_PASCAL_CASE"] != LintSeverityError {
+        t.Errorf("unexpected severities: %v", severities)
+    }
+
+    for _, rules := range []map[string]string{{"NO_SUCH_RULE": LintSeverityError}, {"COMMENT_MESSAGE": "fatal"}} {
+        pm.Config.Lint.Rules = rules
+        if _, err := pm.lintSeverities(); err == nil {
+            t.Errorf("lintSeverities accepted %v", rules)
+        }
+    }
+}
@@ -2,35 +2,66 @@
 package main
 
 import (
+    "context"
+    "errors"
     "flag"
-    "fmt"
     "os"
-    "os/signal"
-    "syscall"
+    "time"
+
+    "github.com/sirupsen/logrus"
 
     "github.com/Cdaprod/protomanager"
     "github.com/Cdaprod/protomanager/cmd"
-    "github.com/Cdaprod/protomanager/cluster"
 )
 
+// shutdownGracePeriod is how long a running command gets to kill its external
+// processes and discard its staged output after a shutdown signal.
+const shutdownGracePeriod = 10 * time.Second
+
 func main() {
     // Initialize logger
     logger := protomanager.NewLogger()
 
     // Parse flags
+    configPath := flag.String("config", "./config.yml", "Path to the configuration file")
     globalProtoPath := flag.String("global-proto", "./proto/global.proto", "Path to the global.proto file")
     microserviceProtoDir := flag.String("proto-dir", "./proto/microservices", "Directory to store microservice proto files")
     outputDir := flag.String("output-dir", "./generated", "Directory for generated protobuf code")
+    registryPath := flag.String("registry", "./proto/registry.json", "Path to the internal registry file")
+    diagnosticsFormat := flag.String("diagnostics", "text", "Format of compiler and hook diagnostics: text, github or json")
     flag.Parse()
 
+    // Load configuration; explicitly set flags take precedence over the file
+    config := protomanager.DefaultConfig()
+    if _, err := os.Stat(*configPath); err == nil {
+        if config, err = protomanager.LoadConfig(*configPath); err != nil {
+            logger.Fatalf("Failed to load configuration: %v", err)
+        }
+    }
+    setFlags := map[string]bool{}
+    flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+    if !setFlags["global-proto"] {
+        *globalProtoPath = config.GlobalProtoPath
+    }
+    if !setFlags["proto-dir"] {
+        *microserviceProtoDir = config.MicroserviceProtoDir
+    }
+    if !setFlags["output-dir"] {
+        *outputDir = config.OutputDir
+    }
+
+    // Load the internal registry
+    registry, err := protomanager.LoadInternalProtoRegistry(*registryPath)
+    if err != nil {
+        logger.Fatalf("Failed to load registry: %v", err)
+    }
+
     // Initialize ProtoManager
-    pm, err := protomanager.NewProtoManager(nil, *globalProtoPath, *microserviceProtoDir, *outputDir, logger)
+    pm, err := protomanager.NewProtoManager(registry, *globalProtoPath, *microserviceProtoDir, *outputDir, logger)
     if err != nil {
         logger.Fatalf("Failed to initialize ProtoManager: %v", err)
     }
-
-    // Initialize ClusterManager
-    clusterManager := cluster.NewClusterManager[string]()
+    pm.Config = config
 
     // Subscribe to events
     pm.AddEventListener(func(event protomanager.Event) {
@@ -44,6 +75,11 @@ func main() {
         }
     })
 
+    // Cancelling ctx stops the running command and its external processes
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    shutdown := make(chan struct{})
+
     // Initialize the signal handler
     signalHandler := protomanager.NewSignalHandler()
 
@@ -56,8 +92,9 @@ func main() {
             switch action {
             case "shutdown":
                 logger.Info("Shutting down ProtoManager...")
-                // Perform any necessary cleanup here
-                os.Exit(0)
+                cancel()
+                close(shutdown)
+                return
             case "reload":
                 logger.Info("Reloading configuration...")
                 // Implement reload logic if applicable
@@ -65,11 +102,51 @@ func main() {
         }
     }()
 
-    // Execute CLI commands
+    // Execute CLI commands; only main exits, once the command has returned
+    result := make(chan error, 1)
     go func() {
-        cmd.Execute()
+        result <- cmd.Execute(ctx, pm, flag.Args())
     }()
 
-    // Keep the main goroutine alive
-    select {}
-}
\ No newline at end of file
+    var cmdErr error
+    select {
+    case cmdErr = <-result:
+        if cmdErr == nil && flag.NArg() == 0 {
+            // Without a one-shot command, keep running until shut down
+            <-shutdown
+        }
+    case <-shutdown:
+        select {
+        case cmdErr = <-result:
+        case <-time.After(shutdownGracePeriod):
+            logger.Warn("Timed out waiting for the running command to stop")
+        }
+    }
+    os.Exit(exitCode(logger, cmdErr, *diagnosticsFormat))
+}
+
+// exitCode reports the outcome of a command and returns the process exit
+// code. A command stopped by a shutdown signal exits cleanly.
+func exitCode(logger *logrus.Logger, err error, diagnosticsFormat string) int {
+    switch {
+    case err == nil:
+        return 0
+    case errors.Is(err, context.Canceled):
+        logger.Info("Command stopped")
+        return 0
+    }
+
+    // Machine-readable formats go to stdout for CI to pick up
+    out := os.Stdout
+    if diagnosticsFormat == "text" {
+        out = os.Stderr
+    }
+    if printErr := cmd.PrintDiagnostics(out, err, diagnosticsFormat); printErr != nil {
+        logger.Errorf("Failed to print diagnostics: %v", printErr)
+    }
+    // Text diagnostics already carry the error's details
+    if diagnosticsFormat != "text" || len(protomanager.Diagnostics(err)) == 0 {
+        logger.Errorf("Command failed: %v", err)
+    }
+    return 1
+}
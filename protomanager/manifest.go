@@ -0,0 +1,298 @@
+// protomanager/manifest.go
+package protomanager
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// manifestsDir is the subdirectory of OutputDir holding output manifests.
+const manifestsDir = "manifests"
+
+// toolVersionTimeout bounds `--version` probes of protoc and plugins.
+const toolVersionTimeout = 30 * time.Second
+
+// OutputTarget identifies one generation run: a package in one language.
+type OutputTarget struct {
+    Package  string // Service name, or "global" for the global proto file
+    Language string
+    Version  string // ServiceMetadata.Version of the package
+    Domain   string // ServiceMetadata.Domain of the package
+
+    Dir   string       // Language output directory, where Hooks run
+    Hooks []HookConfig // Post-generation steps
+}
+
+// key returns the cache and manifest key of the target, e.g. "billing/go".
+func (t OutputTarget) key() string {
+    return t.Package + "/" + t.Language
+}
+
+// OutputManifest records what a generation run produced and from what.
+type OutputManifest struct {
+    Package   string          `json:"package"`
+    Language  string          `json:"language"`
+    Version   string          `json:"version"`
+    Generator string          `json:"generator"`
+    Protoc    string          `json:"protoc,omitempty"` // protoc version, when generated with protoc
+    Plugins   []PluginVersion `json:"plugins"`
+    Sources   []ManifestFile  `json:"sources"` // Proto files, by import name
+    Files     []ManifestFile  `json:"files"`   // Generated files, relative to OutputDir
+}
+
+// PluginVersion records a plugin used by a generation run.
+type PluginVersion struct {
+    Name    string   `json:"name"`
+    Version string   `json:"version"`
+    Options []string `json:"options,omitempty"`
+}
+
+// ManifestFile is a file together with the hex SHA-256 of its content.
+type ManifestFile struct {
+    Path   string `json:"path"`
+    SHA256 string `json:"sha256"`
+}
+
+// VerifyIssue describes a generated file that no longer matches its manifest.
+type VerifyIssue struct {
+    Manifest string // Path of the manifest listing the file
+    Path     string // Generated file, relative to OutputDir
+    Problem  string // "missing" or "modified"
+}
+
+// String implements fmt.Stringer.
+func (i VerifyIssue) String() string {
+    return fmt.Sprintf("%s: %s (listed in %s)", i.Problem, i.Path, i.Manifest)
+}
+
+// OutputManifestPath returns where the manifest of a package and language is written.
+func (pm *ProtoManager) OutputManifestPath(packageName, language string) string {
+    return filepath.Join(pm.OutputDir, manifestsDir, packageName, language+".json")
+}
+
+// ReadOutputManifest loads the manifest of a package and language; it returns
+// nil without error when none has been written yet.
+func (pm *ProtoManager) ReadOutputManifest(packageName, language string) (*OutputManifest, error) {
+    data, err := os.ReadFile(pm.OutputManifestPath(packageName, language))
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    manifest := &OutputManifest{}
+    if err := json.Unmarshal(data, manifest); err != nil {
+        return nil, fmt.Errorf("failed to parse manifest for '%s/%s': %w", packageName, language, err)
+    }
+    return manifest, nil
+}
+
+// writeOutputManifest records the files generated for target from req.
+func (pm *ProtoManager) writeOutputManifest(ctx context.Context, target OutputTarget, req GenerateRequest, files []string) (*OutputManifest, error) {
+    manifest := &OutputManifest{
+        Package:   target.Package,
+        Language:  target.Language,
+        Version:   target.Version,
+        Generator: pm.backendName(),
+    }
+    if manifest.Generator == "protoc" {
+        manifest.Protoc = toolVersion(ctx, pm.protocBinary())
+    }
+
+    for _, plugin := range req.Plugins {
+        version := manifest.Protoc
+        if plugin.Binary != "" || !builtinProtocPlugins[plugin.Name] {
+            version = toolVersion(ctx, pm.pluginBinary(plugin))
+        }
+        manifest.Plugins = append(manifest.Plugins, PluginVersion{Name: plugin.Name, Version: version, Options: plugin.Options})
+    }
+
+    resolver := &ImportResolver{IncludePaths: req.IncludePaths}
+    for _, file := range req.Files {
+        name, err := resolver.NameOf(file)
+        if err != nil {
+            return nil, err
+        }
+        digest, err := fileDigest(file)
+        if err != nil {
+            return nil, err
+        }
+        manifest.Sources = append(manifest.Sources, ManifestFile{Path: name, SHA256: digest})
+    }
+
+    for _, file := range files {
+        digest, err := fileDigest(file)
+        if err != nil {
+            return nil, err
+        }
+        rel, err := filepath.Rel(pm.OutputDir, file)
+        if err != nil {
+            return nil, err
+        }
+        manifest.Files = append(manifest.Files, ManifestFile{Path: filepath.ToSlash(rel), SHA256: digest})
+    }
+    sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+    data, err := json.MarshalIndent(manifest, "", "  ")
+    if err != nil {
+        return nil, err
+    }
+    path := pm.OutputManifestPath(target.Package, target.Language)
+    if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+        return nil, err
+    }
+    return manifest, writeFileAtomic(path, append(data, '\n'), 0644)
+}
+
+// manifestFilesExist reports whether every file listed in the manifest of
+// target is still present.
+func (pm *ProtoManager) manifestFilesExist(target OutputTarget) bool {
+    manifest, err := pm.ReadOutputManifest(target.Package, target.Language)
+    if err != nil || manifest == nil {
+        return false
+    }
+    for _, file := range manifest.Files {
+        if _, err := os.Stat(filepath.Join(pm.OutputDir, filepath.FromSlash(file.Path))); err != nil {
+            return false
+        }
+    }
+    return true
+}
+
+// VerifyOutputs checks every generated file listed in the output manifests
+// against its recorded hash, reporting files that are missing or were edited
+// by hand.
+func (pm *ProtoManager) VerifyOutputs() ([]VerifyIssue, error) {
+    manifests, err := pm.listManifests()
+    if err != nil {
+        return nil, err
+    }
+    if len(manifests) == 0 {
+        return nil, fmt.Errorf("no output manifests found in '%s'; run `protomanager generate` first", filepath.Join(pm.OutputDir, manifestsDir))
+    }
+
+    issues, err := pm.verifyManifests(manifests)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(issues) > 0 {
+        pm.Logger.Warnf("%d generated file(s) do not match their manifests", len(issues))
+        pm.emitEvent(Event{Type: "VerificationFailed", Message: fmt.Sprintf("%d generated file(s) do not match their manifests", len(issues))})
+    } else {
+        pm.Logger.Infof("Verified generated files against %d manifest(s)", len(manifests))
+    }
+    return issues, nil
+}
+
+// listManifests returns the paths of all output manifests, sorted.
+func (pm *ProtoManager) listManifests() ([]string, error) {
+    root := filepath.Join(pm.OutputDir, manifestsDir)
+    var manifests []string
+    err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if !d.IsDir() && filepath.Ext(p) == ".json" {
+            manifests = append(manifests, p)
+        }
+        return nil
+    })
+    if err != nil && !os.IsNotExist(err) {
+        return nil, err
+    }
+    sort.Strings(manifests)
+    return manifests, nil
+}
+
+// verifyManifests compares the files listed in the given manifests with
+// their recorded hashes.
+func (pm *ProtoManager) verifyManifests(manifests []string) ([]VerifyIssue, error) {
+    var issues []VerifyIssue
+    for _, path := range manifests {
+        data, err := os.ReadFile(path)
+        if err != nil {
+            return nil, err
+        }
+        var manifest OutputManifest
+        if err := json.Unmarshal(data, &manifest); err != nil {
+            return nil, fmt.Errorf("failed to parse manifest '%s': %w", path, err)
+        }
+
+        for _, file := range manifest.Files {
+            digest, err := fileDigest(filepath.Join(pm.OutputDir, filepath.FromSlash(file.Path)))
+            switch {
+            case os.IsNotExist(err):
+                issues = append(issues, VerifyIssue{Manifest: path, Path: file.Path, Problem: "missing"})
+            case err != nil:
+                return nil, err
+            case digest != file.SHA256:
+                issues = append(issues, VerifyIssue{Manifest: path, Path: file.Path, Problem: "modified"})
+            }
+        }
+    }
+    return issues, nil
+}
+
+// generateStaged runs req with every plugin writing to a scratch directory,
+// then copies the results into the real output directories. It returns the
+// paths of the files written, which protoc alone cannot tell us.
+func (pm *ProtoManager) generateStaged(ctx context.Context, req GenerateRequest) ([]string, error) {
+    staging, err := os.MkdirTemp("", "protomanager-generate-*")
+    if err != nil {
+        return nil, fmt.Errorf("failed to create staging directory: %w", err)
+    }
+    defer os.RemoveAll(staging)
+
+    staged := req
+    staged.Plugins = make([]PluginInvocation, len(req.Plugins))
+    for i, plugin := range req.Plugins {
+        plugin.OutDir = filepath.Join(staging, strconv.Itoa(i))
+        if err := os.MkdirAll(plugin.OutDir, os.ModePerm); err != nil {
+            return nil, err
+        }
+        staged.Plugins[i] = plugin
+    }
+
+    if err := pm.Generate(ctx, staged); err != nil {
+        return nil, err
+    }
+
+    seen := map[string]bool{}
+    for i, plugin := range staged.Plugins {
+        files, err := listFiles(plugin.OutDir)
+        if err != nil {
+            return nil, err
+        }
+        for rel := range files {
+            dst := filepath.Join(req.Plugins[i].OutDir, filepath.FromSlash(rel))
+            if err := copyFile(filepath.Join(plugin.OutDir, filepath.FromSlash(rel)), dst); err != nil {
+                return nil, err
+            }
+            seen[dst] = true
+        }
+    }
+    return sortedKeys(seen), nil
+}
+
+// toolVersion returns the first line printed by `binary --version`, or
+// "unknown" when the executable cannot report it in time.
+func toolVersion(ctx context.Context, binary string) string {
+    output, err := NewCommand(ctx, toolVersionTimeout, binary, "--version").Output()
+    if err != nil {
+        return "unknown"
+    }
+    version := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+    if version == "" {
+        return "unknown"
+    }
+    return version
+}
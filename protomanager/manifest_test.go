@@ -0,0 +1,71 @@
+// protomanager/manifest_test.go
+package protomanager
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestVerifyOutputs(t *testing.T) {
+    const (
+        a = "billing/go/a.pb.go"
+        b = "billing/go/b.pb.go"
+    )
+    tests := []struct {
+        name   string
+        change map[string]string // Files rewritten after generation
+        remove []string          // Files deleted after generation
+        want   []VerifyIssue
+    }{
+        {name: "clean"},
+        {name: "modified", change: map[string]string{a: "edited"}, want: []VerifyIssue{{Path: a, Problem: "modified"}}},
+        {name: "missing", remove: []string{b}, want: []VerifyIssue{{Path: b, Problem: "missing"}}},
+        {name: "untracked files are ignored", change: map[string]string{"billing/go/extra.go": "mine"}},
+        {
+            name:   "modified and missing",
+            change: map[string]string{b: "edited"},
+            remove: []string{a},
+            want:   []VerifyIssue{{Path: a, Problem: "missing"}, {Path: b, Problem: "modified"}},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            pm := newTestProtoManager(t)
+            writeProtos(t, pm.OutputDir, map[string]string{a: "a", b: "b"})
+            data, err := json.Marshal(manifestOf(t, pm, a, b))
+            if err != nil {
+                t.Fatal(err)
+            }
+            manifest := pm.OutputManifestPath("billing", "go")
+            writeProtos(t, filepath.Dir(manifest), map[string]string{filepath.Base(manifest): string(data)})
+
+            writeProtos(t, pm.OutputDir, tt.change)
+            for _, path := range tt.remove {
+                if err := os.Remove(pm.outputPath(path)); err != nil {
+                    t.Fatal(err)
+                }
+            }
+
+            issues, err := pm.VerifyOutputs()
+            if err != nil {
+                t.Fatal(err)
+            }
+            for i := range tt.want {
+                tt.want[i].Manifest = manifest
+            }
+            if len(issues) != 0 || len(tt.want) != 0 {
+                if !reflect.DeepEqual(issues, tt.want) {
+                    t.Errorf("VerifyOutputs() = %v, want %v", issues, tt.want)
+                }
+            }
+        })
+    }
+
+    pm := newTestProtoManager(t)
+    if _, err := pm.VerifyOutputs(); err == nil {
+        t.Error("VerifyOutputs() succeeded without any manifests")
+    }
+}
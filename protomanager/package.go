@@ -0,0 +1,783 @@
+// protomanager/package.go
+package protomanager
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Artifact kinds written by PackageArtifacts.
+const (
+    ArtifactGoModule    = "go-module"
+    ArtifactPythonSdist = "python-sdist"
+    ArtifactPythonWheel = "python-wheel"
+    ArtifactNPM         = "npm"
+)
+
+const (
+    // artifactIndexFile lists every artifact in the artifact directory.
+    artifactIndexFile = "index.json"
+    // artifactChecksumFile holds the artifacts' SHA-256 in sha256sum format.
+    artifactChecksumFile = "SHA256SUMS"
+)
+
+// versionPattern matches ServiceMetadata.Version values such as "v1",
+// "v1.2.3" and "v1beta1".
+var versionPattern = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-?([0-9A-Za-z][0-9A-Za-z.-]*))?$`)
+
+// PackageConfig configures the distributable artifacts written by
+// PackageArtifacts.
+type PackageConfig struct {
+    // Dir is the local artifact directory.
+    Dir string `yaml:"dir"`
+    // Python configures the sdist and wheel of "python" targets.
+    Python PythonPackageConfig `yaml:"python"`
+    // NPM configures the tarball of "typescript" targets.
+    NPM NPMPackageConfig `yaml:"npm"`
+}
+
+// PythonPackageConfig configures Python distributions.
+type PythonPackageConfig struct {
+    // Name is a text/template for the distribution name, rendered with FileOptionsData.
+    Name string `yaml:"name"`
+    // Requires lists the runtime requirements, e.g. "protobuf>=4.25".
+    Requires []string `yaml:"requires"`
+}
+
+// NPMPackageConfig configures npm packages.
+type NPMPackageConfig struct {
+    // Name is a text/template for the package name, rendered with FileOptionsData.
+    Name string `yaml:"name"`
+    // Dependencies maps runtime packages to version ranges.
+    Dependencies map[string]string `yaml:"dependencies"`
+}
+
+// PackageOptions selects what PackageArtifacts bundles.
+type PackageOptions struct {
+    Services  []string // Registered services; empty packages every one
+    Languages []string // Target languages; empty packages every generated one
+    Version   string   // Overrides ServiceMetadata.Version
+}
+
+// Artifact is a distributable file in the artifact directory.
+type Artifact struct {
+    Service  string `json:"service"`
+    Language string `json:"language"`
+    Kind     string `json:"kind"`
+    Name     string `json:"name"`    // Module path, distribution or package name
+    Version  string `json:"version"` // Version in the ecosystem's own format
+    Path     string `json:"path"`    // Relative to the artifact directory
+    SHA256   string `json:"sha256"`
+    Size     int64  `json:"size"`
+}
+
+// artifactIndex is the content of the index file.
+type artifactIndex struct {
+    Artifacts []Artifact `json:"artifacts"`
+}
+
+// archiveEntry is a file inside an archive.
+type archiveEntry struct {
+    Name string // Slash-separated path inside the archive
+    Data []byte
+}
+
+// artifactVersion is a parsed ServiceMetadata.Version.
+type artifactVersion struct {
+    Major, Minor, Patch int
+    Pre                 string
+}
+
+// PackageArtifacts bundles the generated code of each service and language
+// into versioned artifacts in Config.Package.Dir: a Go module zip in module
+// proxy layout for Go targets, a Python sdist and wheel for "python" and an
+// npm tarball for "typescript". Files are taken from the output manifests
+// and must still match them. The index file and SHA256SUMS are updated to
+// cover every artifact in the directory. It returns the artifacts written.
+func (pm *ProtoManager) PackageArtifacts(ctx context.Context, opts PackageOptions) ([]Artifact, error) {
+    services, err := pm.ProtoRegistry.ListServices()
+    if err != nil {
+        pm.Logger.Errorf("Failed to list registered services: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to list registered services: %v", err)})
+        return nil, err
+    }
+    names := opts.Services
+    if len(names) == 0 {
+        names = sortedKeys(services)
+    }
+
+    var artifacts []Artifact
+    packaged := map[string]bool{}
+    for _, name := range names {
+        metadata, ok := services[name]
+        if !ok {
+            err = fmt.Errorf("service '%s' is not registered", name)
+            break
+        }
+        var written []Artifact
+        written, err = pm.packageService(ctx, name, metadata, opts, packaged)
+        artifacts = append(artifacts, written...)
+        if err != nil {
+            break
+        }
+    }
+
+    // Artifacts written before a failure are still indexed.
+    if indexErr := pm.updateArtifactIndex(artifacts); indexErr != nil && err == nil {
+        err = fmt.Errorf("failed to update artifact index: %w", indexErr)
+    }
+    if err != nil {
+        return artifacts, err
+    }
+    pm.Logger.Infof("Packaged %d artifact(s) into '%s'", len(artifacts), pm.Config.Package.Dir)
+    pm.emitEvent(Event{Type: "ArtifactsPackaged", Message: fmt.Sprintf("Packaged %d artifact(s) into '%s'", len(artifacts), pm.Config.Package.Dir)})
+    return artifacts, nil
+}
+
+// packageService writes the artifacts of one service. packaged holds the
+// Go module directories already packaged by this run.
+func (pm *ProtoManager) packageService(ctx context.Context, name string, metadata ServiceMetadata, opts PackageOptions, packaged map[string]bool) ([]Artifact, error) {
+    raw := metadata.Version
+    if opts.Version != "" {
+        raw = opts.Version
+    }
+    version, err := parseArtifactVersion(raw)
+    if err != nil {
+        return nil, fmt.Errorf("cannot package '%s': %w", name, err)
+    }
+
+    // Without explicit services, only what has been generated is packaged.
+    generated, err := pm.generatedLanguages(name)
+    if err != nil {
+        return nil, err
+    }
+    languages := opts.Languages
+    if len(languages) == 0 {
+        languages = generated
+        if len(languages) == 0 && len(opts.Services) > 0 {
+            return nil, fmt.Errorf("nothing generated for '%s'; run `protomanager generate --packages %s` first", name, name)
+        }
+    } else if len(opts.Services) == 0 {
+        languages = intersect(languages, generated)
+    }
+
+    var artifacts []Artifact
+    for _, lang := range languages {
+        if err := ctx.Err(); err != nil {
+            return artifacts, err
+        }
+        var written []Artifact
+        switch {
+        case goLanguages[lang]:
+            written, err = pm.packageGoModule(name, lang, version, packaged)
+        case lang == "python":
+            written, err = pm.packagePython(name, lang, metadata, version)
+        case lang == "typescript":
+            written, err = pm.packageNPM(name, lang, metadata, version)
+        default:
+            if len(opts.Languages) > 0 {
+                return artifacts, fmt.Errorf("no artifact format for language '%s'; use go, gateway, python or typescript", lang)
+            }
+            pm.Logger.Debugf("No artifact format for '%s/%s'; skipping", name, lang)
+            continue
+        }
+        artifacts = append(artifacts, written...)
+        if err != nil {
+            return artifacts, fmt.Errorf("failed to package '%s/%s': %w", name, lang, err)
+        }
+    }
+    return artifacts, nil
+}
+
+// generatedLanguages returns the languages with an output manifest for a service.
+func (pm *ProtoManager) generatedLanguages(serviceName string) ([]string, error) {
+    entries, err := os.ReadDir(filepath.Join(pm.OutputDir, manifestsDir, serviceName))
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    var languages []string
+    for _, entry := range entries {
+        if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+            languages = append(languages, strings.TrimSuffix(entry.Name(), ".json"))
+        }
+    }
+    return languages, nil
+}
+
+// intersect returns the elements of a that are also in b, in a's order.
+func intersect(a, b []string) []string {
+    in := map[string]bool{}
+    for _, s := range b {
+        in[s] = true
+    }
+    var out []string
+    for _, s := range a {
+        if in[s] {
+            out = append(out, s)
+        }
+    }
+    return out
+}
+
+// generatedFiles returns the files generated for a service and language,
+// relative to its language output directory, after checking them against
+// the output manifest.
+func (pm *ProtoManager) generatedFiles(serviceName, lang string) ([]archiveEntry, error) {
+    manifest, err := pm.ReadOutputManifest(serviceName, lang)
+    if err != nil {
+        return nil, err
+    }
+    if manifest == nil {
+        return nil, fmt.Errorf("no output manifest; run `protomanager generate --packages %s --languages %s` first", serviceName, lang)
+    }
+    language, err := pm.Language(serviceName, lang)
+    if err != nil {
+        return nil, err
+    }
+    dir := pm.LanguageOutputDir(serviceName, language, lang)
+    prefix := pm.relativeOutputPath(dir) + "/"
+
+    var entries []archiveEntry
+    for _, file := range manifest.Files {
+        if !strings.HasPrefix(file.Path, prefix) {
+            continue
+        }
+        data, err := os.ReadFile(filepath.Join(pm.OutputDir, filepath.FromSlash(file.Path)))
+        if err != nil {
+            return nil, err
+        }
+        sum := sha256.Sum256(data)
+        if hex.EncodeToString(sum[:]) != file.SHA256 {
+            return nil, fmt.Errorf("'%s' does not match its manifest; run `protomanager generate --force`", file.Path)
+        }
+        entries = append(entries, archiveEntry{Name: strings.TrimPrefix(file.Path, prefix), Data: data})
+    }
+    if len(entries) == 0 {
+        return nil, fmt.Errorf("the output manifest lists no files under '%s'", dir)
+    }
+    return entries, nil
+}
+
+// writeArtifact writes data to rel below the artifact directory and
+// describes it.
+func (pm *ProtoManager) writeArtifact(rel string, data []byte, artifact Artifact) (Artifact, error) {
+    path := filepath.Join(pm.Config.Package.Dir, filepath.FromSlash(rel))
+    if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+        return artifact, err
+    }
+    if err := writeFileAtomic(path, data, 0644); err != nil {
+        return artifact, err
+    }
+    sum := sha256.Sum256(data)
+    artifact.Path = rel
+    artifact.SHA256 = hex.EncodeToString(sum[:])
+    artifact.Size = int64(len(data))
+    pm.Logger.Infof("Wrote artifact '%s'", path)
+    return artifact, nil
+}
+
+// updateArtifactIndex merges artifacts into the index file, replacing
+// entries with the same path, and rewrites SHA256SUMS from it.
+func (pm *ProtoManager) updateArtifactIndex(artifacts []Artifact) error {
+    dir := pm.Config.Package.Dir
+    indexPath := filepath.Join(dir, artifactIndexFile)
+    index := &artifactIndex{}
+    data, err := readFileIfExists(indexPath)
+    if err != nil {
+        return err
+    }
+    if len(data) > 0 {
+        if err := json.Unmarshal(data, index); err != nil {
+            return fmt.Errorf("failed to parse '%s': %w", indexPath, err)
+        }
+    }
+
+    byPath := map[string]Artifact{}
+    for _, artifact := range append(index.Artifacts, artifacts...) {
+        byPath[artifact.Path] = artifact
+    }
+    index.Artifacts = index.Artifacts[:0]
+    var sums strings.Builder
+    for _, path := range sortedKeys(byPath) {
+        artifact := byPath[path]
+        index.Artifacts = append(index.Artifacts, artifact)
+        fmt.Fprintf(&sums, "%s  %s\n", artifact.SHA256, artifact.Path)
+    }
+
+    if data, err = json.MarshalIndent(index, "", "  "); err != nil {
+        return err
+    }
+    if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+        return err
+    }
+    if err := writeFileAtomic(indexPath, append(data, '\n'), 0644); err != nil {
+        return err
+    }
+    return writeFileAtomic(filepath.Join(dir, artifactChecksumFile), []byte(sums.String()), 0644)
+}
+
+// renderArtifactName renders a Python or npm name template for a service.
+func (pm *ProtoManager) renderArtifactName(tmpl, serviceName string, metadata ServiceMetadata) (string, error) {
+    rendered, err := renderFileOptions(map[string]string{"name": tmpl}, FileOptionsData{
+        Module:  pm.Config.Module,
+        Service: serviceName,
+        Domain:  metadata.Domain,
+        Version: metadata.Version,
+    })
+    if err != nil {
+        return "", err
+    }
+    if rendered["name"] == "" {
+        return "", fmt.Errorf("name template '%s' rendered empty", tmpl)
+    }
+    return rendered["name"], nil
+}
+
+// parseArtifactVersion parses a ServiceMetadata.Version. Missing minor and
+// patch numbers are zero, so "v1" is 1.0.0 and "v1beta1" is 1.0.0-beta1.
+func parseArtifactVersion(version string) (artifactVersion, error) {
+    m := versionPattern.FindStringSubmatch(version)
+    if m == nil {
+        return artifactVersion{}, fmt.Errorf("version '%s' is not of the form v1, v1.2.3 or v1beta1; pass --version", version)
+    }
+    var v artifactVersion
+    v.Major, _ = strconv.Atoi(m[1])
+    v.Minor, _ = strconv.Atoi(m[2])
+    v.Patch, _ = strconv.Atoi(m[3])
+    v.Pre = m[4]
+    return v, nil
+}
+
+// semver returns the version in semantic versioning form, as used by Go
+// (with a "v" prefix) and npm.
+func (v artifactVersion) semver() string {
+    s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+    if v.Pre != "" {
+        s += "-" + v.Pre
+    }
+    return s
+}
+
+// pep440PrePattern matches the pre-release labels PEP 440 can express.
+var pep440PrePattern = regexp.MustCompile(`^(alpha|a|beta|b|rc|c|dev)\.?(\d*)$`)
+
+// pep440 returns the version in the form Python packaging requires.
+func (v artifactVersion) pep440() (string, error) {
+    s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+    if v.Pre == "" {
+        return s, nil
+    }
+    m := pep440PrePattern.FindStringSubmatch(strings.ToLower(v.Pre))
+    if m == nil {
+        return "", fmt.Errorf("pre-release '%s' has no Python equivalent; use alpha, beta, rc or dev", v.Pre)
+    }
+    n := m[2]
+    if n == "" {
+        n = "0"
+    }
+    switch m[1] {
+    case "alpha", "a":
+        return s + "a" + n, nil
+    case "beta", "b":
+        return s + "b" + n, nil
+    case "dev":
+        return s + ".dev" + n, nil
+    }
+    return s + "rc" + n, nil
+}
+
+// sortEntries orders archive entries by name so archives are reproducible.
+func sortEntries(entries []archiveEntry) {
+    sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+}
+
+// packageGoModule writes the Go module holding the code of a service's Go
+// target to the artifact directory in module proxy layout
+// (go/<module>/@v/<version>.{zip,mod,info} plus the list file), so it can
+// be served with GOPROXY=file://<dir>/go. Targets sharing an output
+// directory, such as go and gateway, form one module packaged once.
+func (pm *ProtoManager) packageGoModule(serviceName, lang string, version artifactVersion, packaged map[string]bool) ([]Artifact, error) {
+    language, err := pm.Language(serviceName, lang)
+    if err != nil {
+        return nil, err
+    }
+    dir := pm.LanguageOutputDir(serviceName, language, lang)
+    if packaged[dir] {
+        return nil, nil
+    }
+    packaged[dir] = true
+
+    // Collect the files of every Go target writing into the module.
+    byName := map[string]archiveEntry{}
+    for _, goLang := range sortedKeys(goLanguages) {
+        other, err := pm.Language(serviceName, goLang)
+        if err != nil || pm.LanguageOutputDir(serviceName, other, goLang) != dir {
+            continue
+        }
+        manifest, err := pm.ReadOutputManifest(serviceName, goLang)
+        if err != nil {
+            return nil, err
+        }
+        if manifest == nil && goLang != lang {
+            continue
+        }
+        entries, err := pm.generatedFiles(serviceName, goLang)
+        if err != nil {
+            return nil, err
+        }
+        for _, entry := range entries {
+            byName[entry.Name] = entry
+        }
+    }
+    goMod, ok := byName["go.mod"]
+    if !ok {
+        return nil, fmt.Errorf("no go.mod in '%s'; set go_module.mode to package and regenerate", dir)
+    }
+    modulePath := goModulePath(goMod.Data)
+    if modulePath == "" {
+        return nil, fmt.Errorf("'%s' has no module directive", filepath.Join(dir, "go.mod"))
+    }
+    if version.Major >= 2 && !strings.HasSuffix(modulePath, fmt.Sprintf("/v%d", version.Major)) {
+        return nil, fmt.Errorf("module '%s' must end in /v%d to be released as v%d; add it to go_module.path", modulePath, version.Major, version.Major)
+    }
+
+    entries := make([]archiveEntry, 0, len(byName))
+    for _, name := range sortedKeys(byName) {
+        entries = append(entries, byName[name])
+    }
+    semver := "v" + version.semver()
+    zipData, err := zipArchive(modulePath+"@"+semver, entries)
+    if err != nil {
+        return nil, err
+    }
+    info, err := json.Marshal(struct{ Version string }{semver})
+    if err != nil {
+        return nil, err
+    }
+
+    base := path.Join("go", escapeModulePath(modulePath), "@v")
+    prefix := path.Join(base, escapeModulePath(semver))
+    artifact := Artifact{Service: serviceName, Language: lang, Kind: ArtifactGoModule, Name: modulePath, Version: semver}
+    var artifacts []Artifact
+    for _, file := range []struct {
+        ext  string
+        data []byte
+    }{{".zip", zipData}, {".mod", goMod.Data}, {".info", info}} {
+        written, err := pm.writeArtifact(prefix+file.ext, file.data, artifact)
+        if err != nil {
+            return artifacts, err
+        }
+        artifacts = append(artifacts, written)
+    }
+    return artifacts, pm.addModuleVersion(filepath.Join(pm.Config.Package.Dir, filepath.FromSlash(base), "list"), semver)
+}
+
+// addModuleVersion adds version to a module proxy list file.
+func (pm *ProtoManager) addModuleVersion(listPath, version string) error {
+    data, err := readFileIfExists(listPath)
+    if err != nil {
+        return err
+    }
+    versions := map[string]bool{version: true}
+    for _, line := range strings.Split(string(data), "\n") {
+        if line = strings.TrimSpace(line); line != "" {
+            versions[line] = true
+        }
+    }
+    return writeFileAtomic(listPath, []byte(strings.Join(sortedKeys(versions), "\n")+"\n"), 0644)
+}
+
+// goModulePath returns the module path declared by a go.mod file.
+func goModulePath(goMod []byte) string {
+    for _, line := range strings.Split(string(goMod), "\n") {
+        fields := strings.Fields(line)
+        if len(fields) == 2 && fields[0] == "module" {
+            return strings.Trim(fields[1], `"`)
+        }
+    }
+    return ""
+}
+
+// escapeModulePath applies the module proxy case encoding, replacing each
+// upper-case letter with '!' and its lower-case form.
+func escapeModulePath(s string) string {
+    var sb strings.Builder
+    for _, r := range s {
+        if 'A' <= r && r <= 'Z' {
+            sb.WriteByte('!')
+            r += 'a' - 'A'
+        }
+        sb.WriteRune(r)
+    }
+    return sb.String()
+}
+
+// packagePython writes a source distribution and a pure-Python wheel of a
+// service's Python target to the artifact directory's python subdirectory.
+func (pm *ProtoManager) packagePython(serviceName, lang string, metadata ServiceMetadata, version artifactVersion) ([]Artifact, error) {
+    cfg := pm.Config.Package.Python
+    name, err := pm.renderArtifactName(cfg.Name, serviceName, metadata)
+    if err != nil {
+        return nil, err
+    }
+    pyVersion, err := version.pep440()
+    if err != nil {
+        return nil, err
+    }
+    entries, err := pm.generatedFiles(serviceName, lang)
+    if err != nil {
+        return nil, err
+    }
+    norm := normalizePythonName(name)
+    distName := norm + "-" + pyVersion
+    pkgInfo := pythonMetadata(name, pyVersion, serviceName, cfg.Requires)
+
+    // The sdist builds with setuptools from an explicit list of top-level
+    // modules and packages.
+    entries, packages := pythonPackages(entries)
+    var modules, packageNames []string
+    for _, entry := range entries {
+        if !strings.Contains(entry.Name, "/") && strings.HasSuffix(entry.Name, ".py") {
+            modules = append(modules, strconv.Quote(strings.TrimSuffix(entry.Name, ".py")))
+        }
+    }
+    for _, pkg := range packages {
+        packageNames = append(packageNames, strconv.Quote(pkg))
+    }
+    requires := make([]string, 0, len(cfg.Requires))
+    for _, req := range cfg.Requires {
+        requires = append(requires, strconv.Quote(req))
+    }
+    var pyproject strings.Builder
+    fmt.Fprintf(&pyproject, "[build-system]\nrequires = [\"setuptools>=61\", \"wheel\"]\nbuild-backend = \"setuptools.build_meta\"\n\n")
+    fmt.Fprintf(&pyproject, "[project]\nname = %q\nversion = %q\ndescription = %q\ndependencies = [%s]\n\n",
+        name, pyVersion, fmt.Sprintf("Generated protobuf code for %s", serviceName), strings.Join(requires, ", "))
+    fmt.Fprintf(&pyproject, "[tool.setuptools]\npy-modules = [%s]\npackages = [%s]\n\n", strings.Join(modules, ", "), strings.Join(packageNames, ", "))
+    fmt.Fprintf(&pyproject, "[tool.setuptools.package-data]\n\"*\" = [\"*.pyi\"]\n")
+
+    sdist := append([]archiveEntry{
+        {Name: "PKG-INFO", Data: pkgInfo},
+        {Name: "pyproject.toml", Data: []byte(pyproject.String())},
+    }, entries...)
+    sdistData, err := tarGzArchive(distName, sdist)
+    if err != nil {
+        return nil, err
+    }
+
+    // The wheel installs the generated files as they are, described by its
+    // .dist-info directory.
+    distInfo := distName + ".dist-info/"
+    wheel := append([]archiveEntry{
+        {Name: distInfo + "METADATA", Data: pkgInfo},
+        {Name: distInfo + "WHEEL", Data: []byte("Wheel-Version: 1.0\nGenerator: protomanager\nRoot-Is-Purelib: true\nTag: py3-none-any\n")},
+    }, entries...)
+    var record strings.Builder
+    for _, entry := range wheel {
+        sum := sha256.Sum256(entry.Data)
+        fmt.Fprintf(&record, "%s,sha256=%s,%d\n", entry.Name, base64.RawURLEncoding.EncodeToString(sum[:]), len(entry.Data))
+    }
+    fmt.Fprintf(&record, "%sRECORD,,\n", distInfo)
+    wheel = append(wheel, archiveEntry{Name: distInfo + "RECORD", Data: []byte(record.String())})
+    wheelData, err := zipArchive("", wheel)
+    if err != nil {
+        return nil, err
+    }
+
+    artifact := Artifact{Service: serviceName, Language: lang, Name: name, Version: pyVersion}
+    artifact.Kind = ArtifactPythonSdist
+    sdistArtifact, err := pm.writeArtifact(path.Join("python", distName+".tar.gz"), sdistData, artifact)
+    if err != nil {
+        return nil, err
+    }
+    artifact.Kind = ArtifactPythonWheel
+    wheelArtifact, err := pm.writeArtifact(path.Join("python", distName+"-py3-none-any.whl"), wheelData, artifact)
+    if err != nil {
+        return []Artifact{sdistArtifact}, err
+    }
+    return []Artifact{sdistArtifact, wheelArtifact}, nil
+}
+
+// pythonPackages adds an empty __init__.py to every directory holding Python
+// files, and to its parents, unless the generated code already has one, so
+// the generated directories install as regular packages. It returns the
+// completed entries and the dotted names of the packages.
+func pythonPackages(entries []archiveEntry) ([]archiveEntry, []string) {
+    present := map[string]bool{}
+    for _, entry := range entries {
+        present[entry.Name] = true
+    }
+
+    dirs := map[string]bool{}
+    for _, entry := range entries {
+        if !strings.HasSuffix(entry.Name, ".py") && !strings.HasSuffix(entry.Name, ".pyi") {
+            continue
+        }
+        for dir := path.Dir(entry.Name); dir != "."; dir = path.Dir(dir) {
+            dirs[dir] = true
+        }
+    }
+
+    var packages []string
+    for _, dir := range sortedKeys(dirs) {
+        if init := dir + "/__init__.py"; !present[init] {
+            entries = append(entries, archiveEntry{Name: init})
+        }
+        packages = append(packages, strings.ReplaceAll(dir, "/", "."))
+    }
+    sortEntries(entries)
+    return entries, packages
+}
+
+// pythonMetadata returns the core metadata shared by PKG-INFO and METADATA.
+func pythonMetadata(name, version, serviceName string, requires []string) []byte {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "Metadata-Version: 2.1\nName: %s\nVersion: %s\n", name, version)
+    fmt.Fprintf(&sb, "Summary: Generated protobuf code for %s\n", serviceName)
+    for _, req := range requires {
+        fmt.Fprintf(&sb, "Requires-Dist: %s\n", req)
+    }
+    return []byte(sb.String())
+}
+
+// pythonNameSeparators matches the runs collapsed by name normalization.
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName returns the form of a distribution name used in
+// sdist and wheel file names.
+func normalizePythonName(name string) string {
+    return pythonNameSeparators.ReplaceAllString(strings.ToLower(name), "_")
+}
+
+// npmIndexFile is the entry point of npm packages, re-exporting every
+// generated module.
+const npmIndexFile = "index.ts"
+
+// npmExport is a conditional export of package.json.
+type npmExport struct {
+    Types   string `json:"types"`
+    Default string `json:"default"`
+}
+
+// packageNPM writes an npm tarball of a service's TypeScript target to the
+// artifact directory's npm subdirectory, in the layout `npm publish` accepts.
+// The package ships the TypeScript sources for a bundler or TypeScript-aware
+// runtime: its entry point is an index.ts exposing each generated module as
+// a namespace, and each module is also exported under its own path.
+func (pm *ProtoManager) packageNPM(serviceName, lang string, metadata ServiceMetadata, version artifactVersion) ([]Artifact, error) {
+    cfg := pm.Config.Package.NPM
+    name, err := pm.renderArtifactName(cfg.Name, serviceName, metadata)
+    if err != nil {
+        return nil, err
+    }
+    entries, err := pm.generatedFiles(serviceName, lang)
+    if err != nil {
+        return nil, err
+    }
+    entries, exports := npmEntryPoints(entries)
+
+    manifest := struct {
+        Name         string               `json:"name"`
+        Version      string               `json:"version"`
+        Description  string               `json:"description"`
+        Main         string               `json:"main"`
+        Types        string               `json:"types"`
+        Exports      map[string]npmExport `json:"exports"`
+        Files        []string             `json:"files"`
+        Dependencies map[string]string    `json:"dependencies,omitempty"`
+    }{
+        Name:         name,
+        Version:      version.semver(),
+        Description:  fmt.Sprintf("Generated protobuf code for %s", serviceName),
+        Main:         npmIndexFile,
+        Types:        npmIndexFile,
+        Exports:      exports,
+        Dependencies: cfg.Dependencies,
+    }
+    for _, entry := range entries {
+        manifest.Files = append(manifest.Files, entry.Name)
+    }
+    packageJSON, err := json.MarshalIndent(manifest, "", "  ")
+    if err != nil {
+        return nil, err
+    }
+    entries = append(entries, archiveEntry{Name: "package.json", Data: append(packageJSON, '\n')})
+    data, err := tarGzArchive("package", entries)
+    if err != nil {
+        return nil, err
+    }
+
+    // Scoped packages are named like `npm pack` does: @scope/name becomes scope-name.
+    file := strings.ReplaceAll(strings.TrimPrefix(name, "@"), "/", "-") + "-" + manifest.Version + ".tgz"
+    artifact, err := pm.writeArtifact(path.Join("npm", file), data, Artifact{
+        Service: serviceName, Language: lang, Kind: ArtifactNPM, Name: name, Version: manifest.Version,
+    })
+    if err != nil {
+        return nil, err
+    }
+    return []Artifact{artifact}, nil
+}
+
+// npmEntryPoints returns entries with an index.ts re-exporting each
+// generated module as a namespace, unless the generated code has its own,
+// and the package.json exports: the index as the package root and every
+// module under its path without extension.
+func npmEntryPoints(entries []archiveEntry) ([]archiveEntry, map[string]npmExport) {
+    exports := map[string]npmExport{}
+    var index strings.Builder
+    used := map[string]bool{}
+    hasIndex := false
+    for _, entry := range entries {
+        if entry.Name == npmIndexFile {
+            hasIndex = true
+        }
+        if !strings.HasSuffix(entry.Name, ".ts") || strings.HasSuffix(entry.Name, ".d.ts") || entry.Name == npmIndexFile {
+            continue
+        }
+        module := strings.TrimSuffix(entry.Name, ".ts")
+        exports["./"+module] = npmExport{Types: "./" + entry.Name, Default: "./" + entry.Name}
+        namespace := npmNamespace(module)
+        for n := 2; used[namespace]; n++ {
+            namespace = fmt.Sprintf("%s%d", npmNamespace(module), n)
+        }
+        used[namespace] = true
+        fmt.Fprintf(&index, "export * as %s from \"./%s\";\n", namespace, module)
+    }
+    exports["."] = npmExport{Types: "./" + npmIndexFile, Default: "./" + npmIndexFile}
+
+    if !hasIndex {
+        entries = append(entries, archiveEntry{Name: npmIndexFile, Data: []byte(index.String())})
+        sortEntries(entries)
+    }
+    return entries, exports
+}
+
+// npmIdentifierPattern matches characters not allowed in a TypeScript identifier.
+var npmIdentifierPattern = regexp.MustCompile(`[^A-Za-z0-9_$]`)
+
+// npmNamespace returns the namespace index.ts exports a module under, e.g.
+// "Billing_BillingClient" for "billing/billing.client".
+func npmNamespace(module string) string {
+    parts := strings.Split(module, "/")
+    for i, part := range parts {
+        parts[i] = npmIdentifierPattern.ReplaceAllString(pascalCase(part), "")
+    }
+    ident := strings.Join(parts, "_")
+    if ident == "" || (ident[0] >= '0' && ident[0] <= '9') {
+        ident = "_" + ident
+    }
+    return ident
+}
@@ -0,0 +1,123 @@
+// protomanager/package_test.go
+package protomanager
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestParseArtifactVersion(t *testing.T) {
+    tests := []struct {
+        version string
+        semver  string
+        pep440  string // empty when the version has no Python equivalent
+    }{
+        {"v1", "1.0.0", "1.0.0"},
+        {"1.2", "1.2.0", "1.2.0"},
+        {"v1.2.3", "1.2.3", "1.2.3"},
+        {"v1beta1", "1.0.0-beta1", "1.0.0b1"},
+        {"v1.2.0-beta.2", "1.2.0-beta.2", "1.2.0b2"},
+        {"v2alpha", "2.0.0-alpha", "2.0.0a0"},
+        {"v1.0.0-rc.1", "1.0.0-rc.1", "1.0.0rc1"},
+        {"v1.0.0-dev3", "1.0.0-dev3", "1.0.0.dev3"},
+        {"v1.0.0-snapshot", "1.0.0-snapshot", ""},
+    }
+    for _, tt := range tests {
+        v, err := parseArtifactVersion(tt.version)
+        if err != nil {
+            t.Errorf("parseArtifactVersion(%q): %v", tt.version, err)
+            continue
+        }
+        if got := v.semver(); got != tt.semver {
+            t.Errorf("%q: semver() = %q, want %q", tt.version, got, tt.semver)
+        }
+        got, err := v.pep440()
+        if tt.pep440 == "" {
+            if err == nil {
+                t.Errorf("%q: pep440() = %q, want an error", tt.version, got)
+            }
+        } else if err != nil || got != tt.pep440 {
+            t.Errorf("%q: pep440() = %q, %v; want %q", tt.version, got, err, tt.pep440)
+        }
+    }
+
+    for _, version := range []string{"", "latest", "v", "v1..2"} {
+        if _, err := parseArtifactVersion(version); err == nil {
+            t.Errorf("parseArtifactVersion(%q) succeeded, want an error", version)
+        }
+    }
+}
+
+func TestPythonPackages(t *testing.T) {
+    entries, packages := pythonPackages([]archiveEntry{
+        {Name: "top_pb2.py"},
+        {Name: "billing/v1/billing_pb2.py"},
+        {Name: "billing/v1/billing_pb2.pyi"},
+        {Name: "google/api/__init__.py", Data: []byte("# own\n")},
+        {Name: "google/api/http_pb2.py"},
+        {Name: "README.md"},
+    })
+
+    var names []string
+    for _, entry := range entries {
+        names = append(names, entry.Name)
+    }
+    wantNames := []string{
+        "README.md",
+        "billing/__init__.py",
+        "billing/v1/__init__.py",
+        "billing/v1/billing_pb2.py",
+        "billing/v1/billing_pb2.pyi",
+        "google/__init__.py",
+        "google/api/__init__.py",
+        "google/api/http_pb2.py",
+        "top_pb2.py",
+    }
+    if !reflect.DeepEqual(names, wantNames) {
+        t.Errorf("entries = %q, want %q", names, wantNames)
+    }
+    if want := []string{"billing", "billing.v1", "google", "google.api"}; !reflect.DeepEqual(packages, want) {
+        t.Errorf("packages = %q, want %q", packages, want)
+    }
+    for _, entry := range entries {
+        if entry.Name == "google/api/__init__.py" && string(entry.Data) != "# own\n" {
+            t.Errorf("existing __init__.py was replaced")
+        }
+    }
+}
+
+func TestNPMEntryPoints(t *testing.T) {
+    entries, exports := npmEntryPoints([]archiveEntry{
+        {Name: "billing/billing.ts"},
+        {Name: "billing/billing.client.ts"},
+        {Name: "billing-x/billing.ts"},
+        {Name: "types.d.ts"},
+    })
+
+    var index string
+    for _, entry := range entries {
+        if entry.Name == npmIndexFile {
+            index = string(entry.Data)
+        }
+    }
+    wantIndex := "export * as Billing_Billing from \"./billing/billing\";\n" +
+        "export * as Billing_BillingClient from \"./billing/billing.client\";\n" +
+        "export * as BillingX_Billing from \"./billing-x/billing\";\n"
+    if index != wantIndex {
+        t.Errorf("index.ts =\n%s\nwant\n%s", index, wantIndex)
+    }
+
+    wantExports := map[string]npmExport{
+        ".":                        {Types: "./index.ts", Default: "./index.ts"},
+        "./billing/billing":        {Types: "./billing/billing.ts", Default: "./billing/billing.ts"},
+        "./billing/billing.client": {Types: "./billing/billing.client.ts", Default: "./billing/billing.client.ts"},
+        "./billing-x/billing":      {Types: "./billing-x/billing.ts", Default: "./billing-x/billing.ts"},
+    }
+    if !reflect.DeepEqual(exports, wantExports) {
+        t.Errorf("exports = %v, want %v", exports, wantExports)
+    }
+
+    if got := npmNamespace("1/a.b"); got != "_1_AB" {
+        t.Errorf("npmNamespace(\"1/a.b\") = %q, want _1_AB", got)
+    }
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+// protomanager/process_other.go
+package protomanager
+
+import (
+    "os/exec"
+)
+
+// killProcessGroup leaves cancellation to exec.CommandContext, which kills
+// only the command itself on platforms without process groups.
+func killProcessGroup(cmd *exec.Cmd) {}
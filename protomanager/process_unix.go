@@ -0,0 +1,19 @@
+//go:build unix
+
+// protomanager/process_unix.go
+package protomanager
+
+import (
+    "os/exec"
+    "syscall"
+)
+
+// killProcessGroup starts cmd in its own process group and makes
+// cancellation kill the whole group, so plugins and helpers spawned by the
+// command do not outlive it.
+func killProcessGroup(cmd *exec.Cmd) {
+    cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+    cmd.Cancel = func() error {
+        return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+    }
+}
@@ -0,0 +1,61 @@
+//go:build unix
+
+// protomanager/process_unix_test.go
+package protomanager
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "syscall"
+    "testing"
+    "time"
+)
+
+// TestCommandTimeoutKillsChildren runs a shell that starts a long sleep and
+// checks that the timeout kills the sleep along with the shell.
+func TestCommandTimeoutKillsChildren(t *testing.T) {
+    pidFile := filepath.Join(t.TempDir(), "child.pid")
+    cmd := NewCommand(context.Background(), 200*time.Millisecond, "sh", "-c", "sleep 30 & echo $! > '"+pidFile+"'; wait")
+
+    start := time.Now()
+    err := cmd.Run()
+    if err == nil || !strings.Contains(err.Error(), "sh timed out after 200ms") {
+        t.Fatalf("Run() error = %v, want a timeout", err)
+    }
+    if elapsed := time.Since(start); elapsed > 10*time.Second {
+        t.Errorf("Run() returned after %s", elapsed)
+    }
+
+    data, err := os.ReadFile(pidFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+    if err != nil {
+        t.Fatal(err)
+    }
+    for deadline := time.Now().Add(5 * time.Second); processAlive(pid); time.Sleep(20 * time.Millisecond) {
+        if time.Now().After(deadline) {
+            syscall.Kill(pid, syscall.SIGKILL)
+            t.Fatalf("child process %d survived the timeout", pid)
+        }
+    }
+}
+
+// processAlive reports whether pid is running; zombies waiting to be reaped
+// count as dead.
+func processAlive(pid int) bool {
+    if syscall.Kill(pid, 0) != nil {
+        return false
+    }
+    stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+    if err != nil {
+        return true
+    }
+    // The state follows the parenthesized command name.
+    fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+    return len(fields) == 0 || fields[0] != "Z"
+}
@@ -5,11 +5,15 @@ package protomanager
 type ProtoRegistry interface {
     RegisterService(serviceName string, metadata ServiceMetadata) error
     GetService(serviceName string) (ServiceMetadata, error)
+    UnregisterService(serviceName string) error
+    ListServices() (map[string]ServiceMetadata, error)
 }
 
 // ServiceMetadata holds metadata about a service.
 type ServiceMetadata struct {
     Domain   string
     Version  string
+    Template string   // Scaffold template used when the service was registered
+    Owners   []string // Teams or people responsible for the service, shown in API docs
     // Additional fields as needed
-}
\ No newline at end of file
+}
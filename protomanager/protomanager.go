@@ -2,9 +2,9 @@
 package protomanager
 
 import (
+    "context"
     "fmt"
     "os"
-    "os/exec"
     "os/signal"
     "sync"
     "syscall"
@@ -16,6 +16,7 @@ import (
 type Event struct {
     Type    string
     Message string
+    Payload interface{} // Structured data for some types, e.g. ServiceMetadata or []Diagnostic
 }
 
 // EventListener is a function that handles events.
@@ -27,10 +28,18 @@ type ProtoManager struct {
     GlobalProtoPath       string
     MicroserviceProtoDir  string
     OutputDir             string
+    Config                *Config
+    Generator             Generator // Overrides the backend selected by Config.Generator
+    Force                 bool      // Regenerate even when the generation cache is up to date
     Logger                *logrus.Logger
     eventListeners        []EventListener
     eventListenersMutex   sync.Mutex
     mu                    sync.Mutex // Ensures safe concurrent access
+    cacheMu               sync.Mutex // Guards the generation cache manifest
+    toolchainMu           sync.Mutex // Serializes toolchain verification and installs
+    goModMu               sync.Mutex // Serializes go.mod scaffolding
+    stageMu               sync.Mutex // Serializes staged generations
+    staged                bool       // OutputDir is a staging copy; see StagedGeneration
 }
 
 // NewProtoManager initializes a new ProtoManager.
@@ -43,6 +52,7 @@ func NewProtoManager(protoRegistry ProtoRegistry, globalProtoPath, microserviceP
         GlobalProtoPath:      globalProtoPath,
         MicroserviceProtoDir: microserviceProtoDir,
         OutputDir:            outputDir,
+        Config:               DefaultConfig(),
         Logger:               logger,
         eventListeners:       []EventListener{},
     }, nil
@@ -77,14 +87,42 @@ func (pm *ProtoManager) emitEvent(event Event) {
     }
 }
 
+// reservedServiceNames are used for the global proto file's own outputs and
+// for the docs index, so services cannot be registered under them.
+var reservedServiceNames = map[string]bool{globalOwner: true, docsIndexPage: true}
+
+// checkServiceName rejects service names that collide with reservedServiceNames.
+func checkServiceName(serviceName string) error {
+    if reservedServiceNames[serviceName] {
+        return fmt.Errorf("service name '%s' is reserved", serviceName)
+    }
+    return nil
+}
+
 // RegisterMicroservice registers a new microservice.
-func (pm *ProtoManager) RegisterMicroservice(serviceName, domain, version string) error {
+func (pm *ProtoManager) RegisterMicroservice(ctx context.Context, serviceName, domain, version string) error {
+    return pm.RegisterMicroserviceWithMetadata(ctx, serviceName, ServiceMetadata{
+        Domain:  domain,
+        Version: version,
+    })
+}
+
+// RegisterMicroserviceWithMetadata registers a new microservice described by metadata.
+func (pm *ProtoManager) RegisterMicroserviceWithMetadata(ctx context.Context, serviceName string, metadata ServiceMetadata) error {
+    if err := checkServiceName(serviceName); err != nil {
+        pm.Logger.Errorf("Failed to register service '%s': %v", serviceName, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to register service '%s': %v", serviceName, err)})
+        return err
+    }
+
     pm.mu.Lock()
     defer pm.mu.Unlock()
 
-    metadata := ServiceMetadata{
-        Domain:  domain,
-        Version: version,
+    // Make sure the service definition renders before touching the registry
+    if _, err := pm.serviceDefinition(serviceName, metadata); err != nil {
+        pm.Logger.Errorf("Failed to render definition for service '%s': %v", serviceName, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to render definition for service '%s': %v", serviceName, err)})
+        return err
     }
 
     // Register service in ProtoRegistry
@@ -95,7 +133,7 @@ func (pm *ProtoManager) RegisterMicroservice(serviceName, domain, version string
     }
 
     pm.Logger.Infof("Successfully registered service '%s'", serviceName)
-    pm.emitEvent(Event{Type: "ServiceRegistered", Message: fmt.Sprintf("Service '%s' registered", serviceName)})
+    pm.emitEvent(Event{Type: "ServiceRegistered", Message: fmt.Sprintf("Service '%s' registered", serviceName), Payload: metadata})
 
     // Update global proto file
     if err := pm.updateGlobalProto(serviceName, metadata); err != nil {
@@ -103,71 +141,168 @@ func (pm *ProtoManager) RegisterMicroservice(serviceName, domain, version string
     }
 
     // Generate code
-    if err := pm.GenerateProtoCode(); err != nil {
+    if err := pm.GenerateProtoCode(ctx); err != nil {
         return err
     }
 
     return nil
 }
 
+// UnregisterMicroservice removes a microservice from the registry and the global proto file.
+func (pm *ProtoManager) UnregisterMicroservice(ctx context.Context, serviceName string) error {
+    pm.mu.Lock()
+    defer pm.mu.Unlock()
+
+    if err := pm.ProtoRegistry.UnregisterService(serviceName); err != nil {
+        pm.Logger.Errorf("Failed to unregister service '%s': %v", serviceName, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to unregister service '%s': %v", serviceName, err)})
+        return err
+    }
+
+    pm.Logger.Infof("Successfully unregistered service '%s'", serviceName)
+    pm.emitEvent(Event{Type: "ServiceUnregistered", Message: fmt.Sprintf("Service '%s' unregistered", serviceName)})
+
+    // Remove the service's block from the global proto file
+    if err := pm.removeFromGlobalProto(serviceName); err != nil {
+        return err
+    }
+
+    // Remove the code generated for the service and regenerate, in one staged swap
+    return pm.StagedGeneration(ctx, func(staged *ProtoManager) error {
+        if _, err := staged.CleanupPackage(serviceName); err != nil {
+            pm.Logger.Errorf("Failed to clean up generated code for service '%s': %v", serviceName, err)
+            pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to clean up generated code for service '%s': %v", serviceName, err)})
+            return err
+        }
+        return staged.GenerateProtoCode(ctx)
+    })
+}
+
 // updateGlobalProto updates the global proto file with the new service.
 func (pm *ProtoManager) updateGlobalProto(serviceName string, metadata ServiceMetadata) error {
     pm.Logger.Infof("Updating global proto file for service '%s'", serviceName)
 
-    serviceDefinition := fmt.Sprintf(`
-service %sService {
-  rpc ExampleRPC (ExampleRequest) returns (ExampleResponse) {}
-}
+    gp, _, err := pm.readGlobalProto()
+    if err != nil {
+        pm.Logger.Errorf("Failed to read global proto file: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to read global proto file: %v", err)})
+        return err
+    }
 
-message ExampleRequest {
-  string message = 1;
-}
+    serviceDefinition, err := pm.serviceDefinition(serviceName, metadata)
+    if err != nil {
+        pm.Logger.Errorf("Failed to load definition for service '%s': %v", serviceName, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to load definition for service '%s': %v", serviceName, err)})
+        return err
+    }
+    if err := pm.storeServiceDefinition(serviceName, serviceDefinition); err != nil {
+        pm.Logger.Errorf("Failed to store definition for service '%s': %v", serviceName, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to store definition for service '%s': %v", serviceName, err)})
+        return err
+    }
 
-message ExampleResponse {
-  string message = 1;
+    // Replace (or add) the service's managed block in the global proto file
+    gp.Services[serviceName] = serviceDefinition
+    content, err := pm.renderGlobalProto(gp)
+    if err != nil {
+        pm.Logger.Errorf("Failed to render global proto file: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to render global proto file: %v", err)})
+        return err
+    }
+    if err := writeFileAtomic(pm.GlobalProtoPath, []byte(content), 0644); err != nil {
+        pm.Logger.Errorf("Failed to write to global proto file: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to write to global proto file: %v", err)})
+        return err
+    }
+
+    pm.Logger.Infof("Global proto file updated for service '%s'", serviceName)
+    pm.emitEvent(Event{Type: "GlobalProtoUpdated", Message: fmt.Sprintf("Global proto updated for service '%s'", serviceName)})
+
+    if _, err := pm.InjectServiceFileOptions(serviceName, metadata); err != nil {
+        pm.Logger.Errorf("Failed to inject file options for service '%s': %v", serviceName, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to inject file options for service '%s': %v", serviceName, err)})
+        return err
+    }
+    return nil
 }
-`, serviceName)
 
-    // Append service definition to global proto file
-    file, err := os.OpenFile(pm.GlobalProtoPath, os.O_APPEND|os.O_WRONLY, 0644)
+// removeFromGlobalProto removes the service's block and stored definition.
+func (pm *ProtoManager) removeFromGlobalProto(serviceName string) error {
+    pm.Logger.Infof("Removing service '%s' from global proto file", serviceName)
+
+    gp, _, err := pm.readGlobalProto()
     if err != nil {
-        pm.Logger.Errorf("Failed to open global proto file: %v", err)
-        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to open global proto file: %v", err)})
+        pm.Logger.Errorf("Failed to read global proto file: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to read global proto file: %v", err)})
         return err
     }
-    defer file.Close()
 
-    if _, err := file.WriteString(serviceDefinition); err != nil {
+    delete(gp.Services, serviceName)
+    content, err := pm.renderGlobalProto(gp)
+    if err != nil {
+        pm.Logger.Errorf("Failed to render global proto file: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to render global proto file: %v", err)})
+        return err
+    }
+    if err := writeFileAtomic(pm.GlobalProtoPath, []byte(content), 0644); err != nil {
         pm.Logger.Errorf("Failed to write to global proto file: %v", err)
         pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to write to global proto file: %v", err)})
         return err
     }
+    if err := os.Remove(pm.serviceDefinitionPath(serviceName)); err != nil && !os.IsNotExist(err) {
+        pm.Logger.Errorf("Failed to remove stored definition for service '%s': %v", serviceName, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to remove stored definition for service '%s': %v", serviceName, err)})
+        return err
+    }
 
-    pm.Logger.Infof("Global proto file updated for service '%s'", serviceName)
-    pm.emitEvent(Event{Type: "GlobalProtoUpdated", Message: fmt.Sprintf("Global proto updated for service '%s'", serviceName)})
+    pm.Logger.Infof("Global proto file updated, service '%s' removed", serviceName)
+    pm.emitEvent(Event{Type: "GlobalProtoUpdated", Message: fmt.Sprintf("Global proto updated, service '%s' removed", serviceName)})
     return nil
 }
 
-// GenerateProtoCode regenerates code from the updated proto files.
-func (pm *ProtoManager) GenerateProtoCode() error {
+// GenerateProtoCode regenerates code from the updated proto files. Output is
+// staged and swapped into OutputDir only when generation succeeds.
+func (pm *ProtoManager) GenerateProtoCode(ctx context.Context) error {
+    return pm.StagedGeneration(ctx, func(staged *ProtoManager) error {
+        return staged.generateProtoCode(ctx)
+    })
+}
+
+// generateProtoCode regenerates code from the global proto file into OutputDir.
+func (pm *ProtoManager) generateProtoCode(ctx context.Context) error {
     pm.Logger.Info("Regenerating code from proto files...")
 
-    cmd := exec.Command("protoc",
-        "--go_out="+pm.OutputDir,
-        "--go-grpc_out="+pm.OutputDir,
-        "--proto_path="+pm.MicroserviceProtoDir,
-        pm.GlobalProtoPath,
-    )
+    includePaths := pm.IncludePaths()
+    if _, err := pm.ResolveImports(includePaths, pm.GlobalProtoPath); err != nil {
+        return err
+    }
 
-    output, err := cmd.CombinedOutput()
+    language, err := pm.Language("", "go")
     if err != nil {
-        pm.Logger.Errorf("Failed to regenerate code: %v\nOutput: %s", err, string(output))
+        return err
+    }
+
+    generated, err := pm.GenerateCached(ctx, OutputTarget{Package: "global", Language: "go", Dir: pm.OutputDir, Hooks: language.PostGenerate}, GenerateRequest{
+        IncludePaths: includePaths,
+        Files:        []string{pm.GlobalProtoPath},
+        Plugins:      language.PluginInvocations(pm.OutputDir),
+    })
+    if err != nil {
+        pm.Logger.Errorf("Failed to regenerate code: %v", err)
         pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to regenerate code: %v", err)})
         return err
     }
+    if generated {
+        pm.Logger.Info("Code regenerated successfully")
+        pm.emitEvent(Event{Type: "CodeGenerated", Message: "Code regenerated successfully"})
+    }
 
-    pm.Logger.Infof("Code regenerated successfully. Output: %s", string(output))
-    pm.emitEvent(Event{Type: "CodeGenerated", Message: "Code regenerated successfully"})
+    // Docs also depend on registry metadata, which the cache does not cover
+    if pm.Config.Docs.Regenerate {
+        if _, err := pm.GenerateDocs(ctx); err != nil {
+            return err
+        }
+    }
     return nil
 }
 
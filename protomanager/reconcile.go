@@ -0,0 +1,137 @@
+// protomanager/reconcile.go
+package protomanager
+
+import (
+    "context"
+    "fmt"
+    "strings"
+)
+
+// ReconcileOptions controls how Reconcile rebuilds the global proto file.
+type ReconcileOptions struct {
+    Check    bool // Report drift without writing anything
+    Generate bool // Regenerate code from the reconciled global proto file
+}
+
+// ReconcileReport describes the drift between the global proto file on disk
+// and the services known to the ProtoRegistry.
+type ReconcileReport struct {
+    Missing  []string // Registered services with no block in the global proto file
+    Orphaned []string // Blocks in the global proto file for unregistered services
+    Modified []string // Blocks whose content differs from the stored definition
+    Updated  bool     // Whether the global proto file was rewritten
+}
+
+// HasDrift reports whether the global proto file differs from the registry.
+func (r *ReconcileReport) HasDrift() bool {
+    return len(r.Missing) > 0 || len(r.Orphaned) > 0 || len(r.Modified) > 0
+}
+
+// String summarizes the report in a single line.
+func (r *ReconcileReport) String() string {
+    if !r.HasDrift() {
+        return "global proto is in sync with the registry"
+    }
+    var parts []string
+    if len(r.Missing) > 0 {
+        parts = append(parts, "missing: "+strings.Join(r.Missing, ", "))
+    }
+    if len(r.Orphaned) > 0 {
+        parts = append(parts, "orphaned: "+strings.Join(r.Orphaned, ", "))
+    }
+    if len(r.Modified) > 0 {
+        parts = append(parts, "modified: "+strings.Join(r.Modified, ", "))
+    }
+    return "drift detected (" + strings.Join(parts, "; ") + ")"
+}
+
+// Reconcile rebuilds the global proto file purely from the registry contents
+// and the stored service definitions, in sorted order. Content outside the
+// managed service blocks is preserved as-is.
+func (pm *ProtoManager) Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconcileReport, error) {
+    pm.mu.Lock()
+    defer pm.mu.Unlock()
+
+    pm.Logger.Info("Reconciling global proto file with registry...")
+
+    services, err := pm.ProtoRegistry.ListServices()
+    if err != nil {
+        pm.Logger.Errorf("Failed to list registered services: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to list registered services: %v", err)})
+        return nil, err
+    }
+
+    current, currentContent, err := pm.readGlobalProto()
+    if err != nil {
+        pm.Logger.Errorf("Failed to read global proto file: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to read global proto file: %v", err)})
+        return nil, err
+    }
+
+    desired := &globalProto{Base: current.Base, Services: make(map[string]string, len(services))}
+    report := &ReconcileReport{}
+    for _, name := range sortedKeys(services) {
+        definition, err := pm.serviceDefinition(name, services[name])
+        if err != nil {
+            pm.Logger.Errorf("Failed to load definition for service '%s': %v", name, err)
+            pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to load definition for service '%s': %v", name, err)})
+            return nil, err
+        }
+        desired.Services[name] = definition
+
+        onDisk, ok := current.Services[name]
+        switch {
+        case !ok:
+            report.Missing = append(report.Missing, name)
+        case onDisk != definition:
+            report.Modified = append(report.Modified, name)
+        }
+    }
+    for _, name := range sortedKeys(current.Services) {
+        if _, ok := services[name]; !ok {
+            report.Orphaned = append(report.Orphaned, name)
+        }
+    }
+
+    if report.HasDrift() {
+        pm.Logger.Warnf("Global proto %s", report)
+        pm.emitEvent(Event{Type: "DriftDetected", Message: report.String()})
+    }
+
+    if opts.Check {
+        return report, nil
+    }
+
+    rendered, err := pm.renderGlobalProto(desired)
+    if err != nil {
+        pm.Logger.Errorf("Failed to render global proto file: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to render global proto file: %v", err)})
+        return nil, err
+    }
+    if rendered != currentContent {
+        if err := writeFileAtomic(pm.GlobalProtoPath, []byte(rendered), 0644); err != nil {
+            pm.Logger.Errorf("Failed to write to global proto file: %v", err)
+            pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to write to global proto file: %v", err)})
+            return nil, err
+        }
+        report.Updated = true
+
+        pm.Logger.Infof("Global proto file rebuilt from %d registered service(s)", len(services))
+        pm.emitEvent(Event{Type: "GlobalProtoReconciled", Message: fmt.Sprintf("Global proto rebuilt from %d registered service(s)", len(services))})
+    }
+
+    for _, name := range sortedKeys(services) {
+        if _, err := pm.InjectServiceFileOptions(name, services[name]); err != nil {
+            pm.Logger.Errorf("Failed to inject file options for service '%s': %v", name, err)
+            pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to inject file options for service '%s': %v", name, err)})
+            return report, err
+        }
+    }
+
+    if opts.Generate {
+        if err := pm.GenerateProtoCode(ctx); err != nil {
+            return report, err
+        }
+    }
+    return report, nil
+}
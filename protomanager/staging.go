@@ -0,0 +1,178 @@
+// protomanager/staging.go
+package protomanager
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+)
+
+// previousOutputSuffix names the rollback copy of OutputDir kept next to it.
+const previousOutputSuffix = ".previous"
+
+// PreviousOutputDir returns where the previous generation is kept for rollback.
+func (pm *ProtoManager) PreviousOutputDir() string {
+    return filepath.Clean(pm.OutputDir) + previousOutputSuffix
+}
+
+// StagedGeneration runs fn against a ProtoManager whose OutputDir is a
+// staging copy of the real one. When fn succeeds and the staged output
+// matches its manifests, the staging directory is swapped into place
+// atomically and the previous generation is kept in PreviousOutputDir.
+// When anything fails, or ctx is cancelled, OutputDir is left untouched.
+func (pm *ProtoManager) StagedGeneration(ctx context.Context, fn func(staged *ProtoManager) error) error {
+    if pm.staged {
+        return fn(pm)
+    }
+
+    pm.stageMu.Lock()
+    defer pm.stageMu.Unlock()
+
+    out := filepath.Clean(pm.OutputDir)
+    if err := os.MkdirAll(filepath.Dir(out), os.ModePerm); err != nil {
+        return err
+    }
+    staging, err := os.MkdirTemp(filepath.Dir(out), filepath.Base(out)+".staging-*")
+    if err != nil {
+        return fmt.Errorf("failed to create staging directory: %w", err)
+    }
+    defer os.RemoveAll(staging)
+
+    if err := copyDir(out, staging); err != nil {
+        return fmt.Errorf("failed to stage output directory: %w", err)
+    }
+
+    err = fn(pm.withOutputDir(staging))
+    if err == nil {
+        err = ctx.Err()
+    }
+    if err != nil {
+        pm.Logger.Warnf("Generation failed; '%s' left unchanged", out)
+        pm.emitEvent(Event{Type: "GenerationAborted", Message: fmt.Sprintf("Generation failed; '%s' left unchanged", out)})
+        return err
+    }
+
+    issues, err := pm.validateStaging(staging)
+    if err != nil {
+        return fmt.Errorf("failed to validate staged output: %w", err)
+    }
+    if len(issues) > 0 {
+        err := fmt.Errorf("staged output does not match its manifests: %v", issues[0])
+        pm.Logger.Errorf("Generation aborted: %v", err)
+        pm.emitEvent(Event{Type: "GenerationAborted", Message: fmt.Sprintf("Generation aborted: %v", err)})
+        return err
+    }
+
+    if err := pm.swapOutput(staging); err != nil {
+        pm.Logger.Errorf("Failed to swap staged output into '%s': %v", out, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to swap staged output into '%s': %v", out, err)})
+        return err
+    }
+
+    pm.Logger.Infof("Swapped staged output into '%s'; previous generation kept in '%s'", out, pm.PreviousOutputDir())
+    pm.emitEvent(Event{Type: "OutputSwapped", Message: fmt.Sprintf("Swapped staged output into '%s'", out)})
+    return nil
+}
+
+// validateStaging verifies the manifests the staged run wrote or changed
+// against the staged files, so a run cannot swap in output that disagrees
+// with its own manifests.
+func (pm *ProtoManager) validateStaging(staging string) ([]VerifyIssue, error) {
+    staged := pm.withOutputDir(staging)
+    manifests, err := staged.listManifests()
+    if err != nil {
+        return nil, err
+    }
+
+    var changed []string
+    for _, path := range manifests {
+        rel, err := filepath.Rel(staging, path)
+        if err != nil {
+            return nil, err
+        }
+        before, err := readFileIfExists(filepath.Join(pm.OutputDir, rel))
+        if err != nil {
+            return nil, err
+        }
+        after, err := os.ReadFile(path)
+        if err != nil {
+            return nil, err
+        }
+        if !bytes.Equal(before, after) {
+            changed = append(changed, path)
+        }
+    }
+    return staged.verifyManifests(changed)
+}
+
+// swapOutput moves staging into OutputDir, keeping the replaced tree as the
+// previous generation.
+func (pm *ProtoManager) swapOutput(staging string) error {
+    out := filepath.Clean(pm.OutputDir)
+    if _, err := os.Stat(out); os.IsNotExist(err) {
+        return os.Rename(staging, out)
+    }
+
+    if err := exchangeDirs(staging, out); err != nil {
+        return err
+    }
+    // staging now holds the previous generation
+    previous := pm.PreviousOutputDir()
+    if err := os.RemoveAll(previous); err != nil {
+        return err
+    }
+    return os.Rename(staging, previous)
+}
+
+// RollbackGeneration swaps the previous generation back into OutputDir. The
+// replaced output becomes the previous generation, so a second rollback
+// restores it.
+func (pm *ProtoManager) RollbackGeneration() error {
+    pm.stageMu.Lock()
+    defer pm.stageMu.Unlock()
+
+    out := filepath.Clean(pm.OutputDir)
+    previous := pm.PreviousOutputDir()
+    if _, err := os.Stat(previous); err != nil {
+        return fmt.Errorf("no previous generation to roll back to in '%s'", previous)
+    }
+
+    var err error
+    if _, statErr := os.Stat(out); os.IsNotExist(statErr) {
+        err = os.Rename(previous, out)
+    } else {
+        err = exchangeDirs(previous, out)
+    }
+    if err != nil {
+        pm.Logger.Errorf("Failed to roll back '%s': %v", out, err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to roll back '%s': %v", out, err)})
+        return err
+    }
+
+    pm.Logger.Infof("Rolled back '%s' to the previous generation", out)
+    pm.emitEvent(Event{Type: "GenerationRolledBack", Message: fmt.Sprintf("Rolled back '%s' to the previous generation", out)})
+    return nil
+}
+
+// withOutputDir returns a copy of pm that generates into dir and forwards
+// events to pm's listeners.
+func (pm *ProtoManager) withOutputDir(dir string) *ProtoManager {
+    pm.eventListenersMutex.Lock()
+    listeners := append([]EventListener{}, pm.eventListeners...)
+    pm.eventListenersMutex.Unlock()
+
+    return &ProtoManager{
+        ProtoRegistry:        pm.ProtoRegistry,
+        GlobalProtoPath:      pm.GlobalProtoPath,
+        MicroserviceProtoDir: pm.MicroserviceProtoDir,
+        OutputDir:            dir,
+        Config:               pm.Config,
+        Generator:            pm.Generator,
+        Force:                pm.Force,
+        Logger:               pm.Logger,
+        eventListeners:       listeners,
+        staged:               true,
+    }
+}
@@ -0,0 +1,120 @@
+// protomanager/staging_test.go
+package protomanager
+
+import (
+    "context"
+    "errors"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+// readTree returns the contents of every file under dir, keyed by relative path.
+func readTree(t *testing.T, dir string) map[string]string {
+    t.Helper()
+    files, err := listFiles(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    tree := map[string]string{}
+    for rel := range files {
+        data, err := os.ReadFile(filepath.Join(dir, rel))
+        if err != nil {
+            t.Fatal(err)
+        }
+        tree[rel] = string(data)
+    }
+    return tree
+}
+
+func TestStagedGeneration(t *testing.T) {
+    original := map[string]string{"billing/go/billing.pb.go": "v1"}
+    write := func(rel, content string) func(staged *ProtoManager) error {
+        return func(staged *ProtoManager) error {
+            writeProtos(t, staged.OutputDir, map[string]string{rel: content})
+            return nil
+        }
+    }
+
+    tests := []struct {
+        name     string
+        fn       func(staged *ProtoManager) error
+        cancel   bool
+        wantErr  bool
+        want     map[string]string // OutputDir afterwards
+        previous map[string]string // PreviousOutputDir afterwards; nil when absent
+    }{
+        {
+            name: "failing fn",
+            fn: func(staged *ProtoManager) error {
+                write("billing/go/billing.pb.go", "v2")(staged)
+                return errors.New("protoc failed")
+            },
+            wantErr: true,
+            want:    original,
+        },
+        {
+            name:    "cancelled context",
+            fn:      write("billing/go/billing.pb.go", "v2"),
+            cancel:  true,
+            wantErr: true,
+            want:    original,
+        },
+        {
+            name:     "success",
+            fn:       write("billing/go/billing.pb.go", "v2"),
+            want:     map[string]string{"billing/go/billing.pb.go": "v2"},
+            previous: original,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            pm := newTestProtoManager(t)
+            writeProtos(t, pm.OutputDir, original)
+
+            ctx, cancel := context.WithCancel(context.Background())
+            defer cancel()
+            if tt.cancel {
+                cancel()
+            }
+            err := pm.StagedGeneration(ctx, func(staged *ProtoManager) error {
+                if staged.OutputDir == pm.OutputDir {
+                    t.Error("fn runs against the real OutputDir")
+                }
+                return tt.fn(staged)
+            })
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("StagedGeneration() error = %v, wantErr %t", err, tt.wantErr)
+            }
+
+            if got := readTree(t, pm.OutputDir); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("OutputDir = %v, want %v", got, tt.want)
+            }
+            if _, err := os.Stat(pm.PreviousOutputDir()); tt.previous == nil && err == nil {
+                t.Error("PreviousOutputDir exists after a failed run")
+            }
+            if tt.previous != nil {
+                if got := readTree(t, pm.PreviousOutputDir()); !reflect.DeepEqual(got, tt.previous) {
+                    t.Errorf("PreviousOutputDir = %v, want %v", got, tt.previous)
+                }
+            }
+            if leftovers, _ := filepath.Glob(filepath.Clean(pm.OutputDir) + ".staging-*"); len(leftovers) != 0 {
+                t.Errorf("staging directories left behind: %v", leftovers)
+            }
+
+            if tt.previous == nil {
+                return
+            }
+            if err := pm.RollbackGeneration(); err != nil {
+                t.Fatal(err)
+            }
+            if got := readTree(t, pm.OutputDir); !reflect.DeepEqual(got, tt.previous) {
+                t.Errorf("OutputDir after rollback = %v, want %v", got, tt.previous)
+            }
+            if got := readTree(t, pm.PreviousOutputDir()); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("PreviousOutputDir after rollback = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
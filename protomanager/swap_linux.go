@@ -0,0 +1,13 @@
+//go:build linux
+
+// protomanager/swap_linux.go
+package protomanager
+
+import (
+    "golang.org/x/sys/unix"
+)
+
+// exchangeDirs atomically swaps the directories at a and b.
+func exchangeDirs(a, b string) error {
+    return unix.Renameat2(unix.AT_FDCWD, a, unix.AT_FDCWD, b, unix.RENAME_EXCHANGE)
+}
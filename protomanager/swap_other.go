@@ -0,0 +1,22 @@
+//go:build !linux
+
+// protomanager/swap_other.go
+package protomanager
+
+import (
+    "os"
+)
+
+// exchangeDirs swaps the directories at a and b. Without an atomic exchange
+// primitive this takes three renames, so readers may briefly see b missing.
+func exchangeDirs(a, b string) error {
+    tmp := b + ".swap"
+    if err := os.Rename(b, tmp); err != nil {
+        return err
+    }
+    if err := os.Rename(a, b); err != nil {
+        os.Rename(tmp, b)
+        return err
+    }
+    return os.Rename(tmp, a)
+}
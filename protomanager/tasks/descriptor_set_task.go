@@ -0,0 +1,35 @@
+// protomanager/tasks/descriptor_set_task.go
+package tasks
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/Cdaprod/protomanager"
+)
+
+// DescriptorSetTask writes the FileDescriptorSet for a registered package.
+type DescriptorSetTask struct {
+    ProtoManager *protomanager.ProtoManager
+    PackageName  string
+}
+
+// Execute runs the descriptor set task.
+func (dt *DescriptorSetTask) Execute(ctx context.Context) (string, error) {
+    pm := dt.ProtoManager
+
+    metadata, err := pm.ProtoRegistry.GetService(dt.PackageName)
+    if err != nil {
+        pm.Logger.Errorf("Failed to look up package '%s': %v", dt.PackageName, err)
+        return "", err
+    }
+
+    path, err := pm.GenerateServiceDescriptorSet(ctx, dt.PackageName, metadata)
+    if err != nil {
+        return "", err
+    }
+    if path == "" {
+        return fmt.Sprintf("No protos to describe for package '%s'", dt.PackageName), nil
+    }
+    return fmt.Sprintf("Descriptor set for package '%s' written to '%s'", dt.PackageName, path), nil
+}
@@ -2,10 +2,9 @@
 package tasks
 
 import (
+    "context"
     "fmt"
     "os"
-    "os/exec"
-    "path/filepath"
 
     "github.com/Cdaprod/protomanager"
 )
@@ -18,36 +17,68 @@ type ProtobufGenerationTask struct {
 }
 
 // Execute runs the protobuf generation task.
-func (pt *ProtobufGenerationTask) Execute() (string, error) {
+func (pt *ProtobufGenerationTask) Execute(ctx context.Context) (string, error) {
     pm := pt.ProtoManager
     pm.Logger.Infof("Generating protobufs for package '%s'", pt.PackageName)
 
-    protoPath := filepath.Join(pm.MicroserviceProtoDir, pt.PackageName, "proto")
-    outDir := filepath.Join(pm.OutputDir, pt.PackageName)
+    metadata, err := pm.ProtoRegistry.GetService(pt.PackageName)
+    if err != nil {
+        pm.Logger.Warnf("Package '%s' is not registered; its manifests will have no version or domain", pt.PackageName)
+    }
+
+    protoPath := pm.ProtoPath(pt.PackageName)
+    files, err := pm.DiscoverProtos(pt.PackageName)
+    if err != nil {
+        pm.Logger.Errorf("Failed to generate protobufs for '%s': %v", pt.PackageName, err)
+        return "", err
+    }
 
     for _, lang := range pt.Languages {
-        outLangDir := filepath.Join(outDir, lang)
-        if err := os.MkdirAll(outLangDir, os.ModePerm); err != nil {
-            pm.Logger.Errorf("Failed to create output directory '%s': %v", outLangDir, err)
+        language, err := pm.Language(pt.PackageName, lang)
+        if err != nil {
+            pm.Logger.Errorf("Failed to generate protobufs for '%s' in '%s': %v", pt.PackageName, lang, err)
             return "", err
         }
 
-        // Build protoc command
-        protocArgs := []string{
-            fmt.Sprintf("--%s_out=%s", lang, outLangDir),
-            fmt.Sprintf("--%s-grpc_out=%s", lang, outLangDir),
-            "--proto_path", protoPath,
-            filepath.Join(protoPath, "*.proto"),
+        outLangDir := pm.LanguageOutputDir(pt.PackageName, language, lang)
+        plugins := language.PluginInvocations(outLangDir)
+        for _, plugin := range plugins {
+            if err := os.MkdirAll(plugin.OutDir, os.ModePerm); err != nil {
+                pm.Logger.Errorf("Failed to create output directory '%s': %v", plugin.OutDir, err)
+                return "", err
+            }
         }
 
-        cmd := exec.Command("protoc", protocArgs...)
-        output, err := cmd.CombinedOutput()
+        target := protomanager.OutputTarget{
+            Package:  pt.PackageName,
+            Language: lang,
+            Version:  metadata.Version,
+            Domain:   metadata.Domain,
+            Dir:      outLangDir,
+            Hooks:    language.PostGenerate,
+        }
+        req := protomanager.GenerateRequest{
+            IncludePaths: pm.IncludePaths(protoPath),
+            Files:        files,
+            Plugins:      plugins,
+        }
+        cleanup := func() {}
+        if language.HTTPRules {
+            if req, cleanup, err = pm.WithDefaultHTTPRules(pt.PackageName, metadata, req); err != nil {
+                pm.Logger.Errorf("Failed to apply default HTTP rules for '%s': %v", pt.PackageName, err)
+                return "", err
+            }
+        }
+        generated, err := pm.GenerateCached(ctx, target, req)
+        cleanup()
         if err != nil {
-            pm.Logger.Errorf("Failed to generate protobufs for '%s' in '%s': %v\nOutput: %s", pt.PackageName, lang, err, string(output))
+            pm.Logger.Errorf("Failed to generate protobufs for '%s' in '%s': %v", pt.PackageName, lang, err)
             return "", err
         }
 
-        pm.Logger.Infof("Successfully generated protobufs for '%s' in '%s'", pt.PackageName, lang)
+        if generated {
+            pm.Logger.Infof("Successfully generated protobufs for '%s' in '%s'", pt.PackageName, lang)
+        }
     }
 
     return fmt.Sprintf("Protobufs generated for package '%s'", pt.PackageName), nil
@@ -2,8 +2,8 @@
 package tasks
 
 import (
+    "context"
     "fmt"
-    "os/exec"
     "path/filepath"
 
     "github.com/Cdaprod/protomanager"
@@ -17,14 +17,14 @@ type PushTask struct {
 }
 
 // Execute runs the push task.
-func (pt *PushTask) Execute() (string, error) {
+func (pt *PushTask) Execute(ctx context.Context) (string, error) {
     pm := pt.ProtoManager
     repoPath := filepath.Join(pm.MicroserviceProtoDir, pt.PackageName)
 
     pm.Logger.Infof("Pushing protobufs for package '%s' to repository at '%s'", pt.PackageName, repoPath)
 
     // Add changes
-    cmdAdd := exec.Command("git", "add", ".")
+    cmdAdd := protomanager.NewCommand(ctx, pm.Config.Timeouts.Git, "git", "add", ".")
     cmdAdd.Dir = repoPath
     if output, err := cmdAdd.CombinedOutput(); err != nil {
         pm.Logger.Errorf("Failed to add changes in '%s': %v\nOutput: %s", repoPath, err, string(output))
@@ -32,7 +32,7 @@ func (pt *PushTask) Execute() (string, error) {
     }
 
     // Commit changes
-    cmdCommit := exec.Command("git", "commit", "-m", pt.CommitMsg)
+    cmdCommit := protomanager.NewCommand(ctx, pm.Config.Timeouts.Git, "git", "commit", "-m", pt.CommitMsg)
     cmdCommit.Dir = repoPath
     if output, err := cmdCommit.CombinedOutput(); err != nil {
         pm.Logger.Errorf("Failed to commit changes in '%s': %v\nOutput: %s", repoPath, err, string(output))
@@ -40,7 +40,7 @@ func (pt *PushTask) Execute() (string, error) {
     }
 
     // Push changes
-    cmdPush := exec.Command("git", "push")
+    cmdPush := protomanager.NewCommand(ctx, pm.Config.Timeouts.Git, "git", "push")
     cmdPush.Dir = repoPath
     if output, err := cmdPush.CombinedOutput(); err != nil {
         pm.Logger.Errorf("Failed to push changes in '%s': %v\nOutput: %s", repoPath, err, string(output))
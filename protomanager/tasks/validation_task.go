@@ -2,9 +2,8 @@
 package tasks
 
 import (
+    "context"
     "fmt"
-    "os/exec"
-    "path/filepath"
 
     "github.com/Cdaprod/protomanager"
 )
@@ -15,18 +14,27 @@ type ValidationTask struct {
     PackageName  string
 }
 
-// Execute runs the validation task.
-func (vt *ValidationTask) Execute() (string, error) {
+// Execute runs the validation task. It lints the package's proto files in
+// process and fails with a *protomanager.LintError when a rule of error
+// severity is violated; warnings are logged.
+func (vt *ValidationTask) Execute(ctx context.Context) (string, error) {
     pm := vt.ProtoManager
-    pkgPath := filepath.Join(pm.MicroserviceProtoDir, vt.PackageName, "proto")
-    cmd := exec.Command("protoc", "--lint_out=.", filepath.Join(pkgPath, "*.proto"))
-
-    output, err := cmd.CombinedOutput()
+    findings, err := pm.LintPackage(ctx, vt.PackageName)
     if err != nil {
-        pm.Logger.Errorf("Validation failed for package '%s': %v\nOutput: %s", vt.PackageName, err, string(output))
+        pm.Logger.Errorf("Validation failed for package '%s': %v", vt.PackageName, err)
+        return "", err
+    }
+
+    for _, f := range findings {
+        if f.Severity == protomanager.LintSeverityWarning {
+            pm.Logger.Warnf("%s", f)
+        }
+    }
+    if err := protomanager.LintErrors(findings); err != nil {
+        pm.Logger.Errorf("Validation failed for package '%s': %v", vt.PackageName, err)
         return "", err
     }
 
     pm.Logger.Infof("Validation successful for package '%s'", vt.PackageName)
     return fmt.Sprintf("Validation successful for package '%s'", vt.PackageName), nil
-}
\ No newline at end of file
+}
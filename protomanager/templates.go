@@ -0,0 +1,136 @@
+// protomanager/templates.go
+package protomanager
+
+import (
+    "embed"
+    "fmt"
+    "os"
+    "path"
+    "sort"
+    "strings"
+    "text/template"
+    "unicode"
+)
+
+// defaultTemplateName is the built-in scaffold used when nothing else is configured.
+const defaultTemplateName = "example"
+
+//go:embed templates/*.proto.tmpl
+var builtinTemplates embed.FS
+
+// ServiceTemplateData is the data passed to service definition templates.
+type ServiceTemplateData struct {
+    ServiceName string
+    Metadata    ServiceMetadata
+}
+
+// templateFuncs are the helper functions available to service definition templates.
+var templateFuncs = template.FuncMap{
+    "pascal": pascalCase,
+    "snake":  snakeCase,
+    "upper":  strings.ToUpper,
+    "lower":  strings.ToLower,
+}
+
+// TemplateNames returns the names of the built-in and configured templates.
+func (pm *ProtoManager) TemplateNames() []string {
+    names := map[string]bool{}
+    entries, _ := builtinTemplates.ReadDir("templates")
+    for _, entry := range entries {
+        names[strings.TrimSuffix(entry.Name(), ".proto.tmpl")] = true
+    }
+    for name := range pm.Config.Templates {
+        names[name] = true
+    }
+
+    list := make([]string, 0, len(names))
+    for name := range names {
+        list = append(list, name)
+    }
+    sort.Strings(list)
+    return list
+}
+
+// renderServiceDefinition renders the scaffold for a newly registered service
+// using the template named in its metadata, or the configured default.
+func (pm *ProtoManager) renderServiceDefinition(serviceName string, metadata ServiceMetadata) (string, error) {
+    name := metadata.Template
+    if name == "" {
+        name = pm.Config.DefaultTemplate
+    }
+    if name == "" {
+        name = defaultTemplateName
+    }
+
+    source, err := pm.templateSource(name)
+    if err != nil {
+        return "", err
+    }
+
+    tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(source)
+    if err != nil {
+        return "", fmt.Errorf("failed to parse template '%s': %w", name, err)
+    }
+
+    var sb strings.Builder
+    if err := tmpl.Execute(&sb, ServiceTemplateData{ServiceName: serviceName, Metadata: metadata}); err != nil {
+        return "", fmt.Errorf("failed to render template '%s' for service '%s': %w", name, serviceName, err)
+    }
+    return normalizeDefinition(sb.String()), nil
+}
+
+// templateSource returns the text of the named template, preferring configured
+// template files over built-in ones.
+func (pm *ProtoManager) templateSource(name string) (string, error) {
+    if file, ok := pm.Config.Templates[name]; ok {
+        data, err := os.ReadFile(file)
+        if err != nil {
+            return "", fmt.Errorf("failed to read template '%s': %w", name, err)
+        }
+        return string(data), nil
+    }
+
+    data, err := builtinTemplates.ReadFile(path.Join("templates", name+".proto.tmpl"))
+    if err != nil {
+        return "", fmt.Errorf("unknown template '%s' (available: %s)", name, strings.Join(pm.TemplateNames(), ", "))
+    }
+    return string(data), nil
+}
+
+// pascalCase converts names such as "user-profile" or "user_profile" to "UserProfile".
+func pascalCase(s string) string {
+    var sb strings.Builder
+    upperNext := true
+    for _, r := range s {
+        switch {
+        case r == '-' || r == '_' || r == ' ' || r == '.':
+            upperNext = true
+        case upperNext:
+            sb.WriteRune(unicode.ToUpper(r))
+            upperNext = false
+        default:
+            sb.WriteRune(r)
+        }
+    }
+    return sb.String()
+}
+
+// snakeCase converts names such as "UserProfile" or "user-profile" to "user_profile".
+func snakeCase(s string) string {
+    var sb strings.Builder
+    runes := []rune(s)
+    for i, r := range runes {
+        switch {
+        case r == '-' || r == ' ' || r == '.':
+            sb.WriteRune('_')
+        case unicode.IsUpper(r):
+            if i > 0 && runes[i-1] != '_' && runes[i-1] != '-' && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+                sb.WriteRune('_')
+            }
+            sb.WriteRune(unicode.ToLower(r))
+        default:
+            sb.WriteRune(r)
+        }
+    }
+    return sb.String()
+}
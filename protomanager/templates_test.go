@@ -0,0 +1,69 @@
+// protomanager/templates_test.go
+package protomanager
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+// newTestProtoManager returns a ProtoManager with the default configuration
+// working in a temporary directory.
+func newTestProtoManager(t *testing.T) *ProtoManager {
+    t.Helper()
+    dir := t.TempDir()
+    pm, err := NewProtoManager(nil, filepath.Join(dir, "proto", "global.proto"), filepath.Join(dir, "proto", "microservices"), filepath.Join(dir, "generated"), NewLogger())
+    if err != nil {
+        t.Fatal(err)
+    }
+    pm.Logger.SetOutput(testWriter{t})
+    return pm
+}
+
+// testWriter sends log output to the test log.
+type testWriter struct{ t *testing.T }
+
+func (w testWriter) Write(p []byte) (int, error) {
+    w.t.Log(strings.TrimRight(string(p), "\n"))
+    return len(p), nil
+}
+
+// writeGlobalProto renders services scaffolded from template into the
+// global proto file of pm.
+func writeGlobalProto(t *testing.T, pm *ProtoManager, template string, services ...string) {
+    t.Helper()
+    gp := &globalProto{Base: defaultGlobalProtoBase, Services: map[string]string{}}
+    for _, name := range services {
+        definition, err := pm.renderServiceDefinition(name, ServiceMetadata{Domain: "pay", Version: "v1", Template: template})
+        if err != nil {
+            t.Fatal(err)
+        }
+        gp.Services[name] = definition
+    }
+    content, err := pm.renderGlobalProto(gp)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := os.MkdirAll(filepath.Dir(pm.GlobalProtoPath), os.ModePerm); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(pm.GlobalProtoPath, []byte(content), 0644); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// TestBuiltinTemplatesCompileTogether registers two services from every
+// built-in template into one global proto file, which must compile.
+func TestBuiltinTemplatesCompileTogether(t *testing.T) {
+    pm := newTestProtoManager(t)
+    for _, name := range pm.TemplateNames() {
+        t.Run(name, func(t *testing.T) {
+            writeGlobalProto(t, pm, name, "myservice", "order-history")
+            if _, _, err := compileProtos(context.Background(), pm.IncludePaths(), []string{pm.GlobalProtoPath}); err != nil {
+                t.Fatalf("global proto does not compile: %v", err)
+            }
+        })
+    }
+}
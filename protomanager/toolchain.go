@@ -0,0 +1,248 @@
+// protomanager/toolchain.go
+package protomanager
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// ToolchainConfig pins protoc and plugin binaries so every machine generates
+// identical code. When a tool is locked, generation runs the verified copy
+// installed in Dir instead of whatever is on PATH.
+type ToolchainConfig struct {
+    // Dir is where verified binaries are installed.
+    Dir string `yaml:"dir"`
+    // ArtifactCache is a local directory of binaries to install from.
+    ArtifactCache string `yaml:"artifact_cache"`
+    // Protoc pins the protoc compiler.
+    Protoc *ToolLock `yaml:"protoc"`
+    // Plugins pins plugins by name, e.g. "go" for protoc-gen-go.
+    Plugins map[string]ToolLock `yaml:"plugins"`
+}
+
+// ToolLock pins one executable.
+type ToolLock struct {
+    Version  string `yaml:"version"`  // Version reported by `<binary> --version`, e.g. "25.1" or "v1.34.2"
+    SHA256   string `yaml:"sha256"`   // Hex SHA-256 of the executable
+    Artifact string `yaml:"artifact"` // Path within ArtifactCache; defaults to <binary>-<version>
+    Binary   string `yaml:"binary"`   // Installed name; defaults to protoc or protoc-gen-<name>
+}
+
+// ToolchainError reports a pinned tool that could not be verified or installed.
+type ToolchainError struct {
+    Tool    string
+    Problem string
+}
+
+// Error implements the error interface.
+func (e *ToolchainError) Error() string {
+    return fmt.Sprintf("toolchain: %s: %s", e.Tool, e.Problem)
+}
+
+// InstalledTool is a pinned tool verified by EnsureToolchain.
+type InstalledTool struct {
+    Name    string
+    Path    string
+    Version string
+}
+
+// locked reports whether any tool is pinned.
+func (tc ToolchainConfig) locked() bool {
+    return tc.Protoc != nil || len(tc.Plugins) > 0
+}
+
+// binaryName returns the installed file name of a pinned tool.
+func (lock ToolLock) binaryName(name string) string {
+    if lock.Binary != "" {
+        return lock.Binary
+    }
+    if name == "protoc" {
+        return "protoc"
+    }
+    return "protoc-gen-" + name
+}
+
+// protocBinary returns the protoc executable generation runs: the pinned copy
+// when protoc is locked, otherwise the configured or PATH binary.
+func (pm *ProtoManager) protocBinary() string {
+    if g, ok := pm.Generator.(*ProtocGenerator); ok && g.Binary != "" {
+        return g.Binary
+    }
+    if lock := pm.Config.Toolchain.Protoc; lock != nil {
+        return filepath.Join(pm.Config.Toolchain.Dir, lock.binaryName("protoc"))
+    }
+    return "protoc"
+}
+
+// pluginBinary returns the executable run for plugin: the pinned copy when
+// the plugin is locked, otherwise its explicit binary or protoc-gen-<name>
+// from PATH. A lock takes priority over the plugin's own binary.
+func (pm *ProtoManager) pluginBinary(plugin PluginInvocation) string {
+    if lock, ok := pm.Config.Toolchain.Plugins[plugin.Name]; ok {
+        return filepath.Join(pm.Config.Toolchain.Dir, lock.binaryName(plugin.Name))
+    }
+    if plugin.Binary != "" {
+        return plugin.Binary
+    }
+    return "protoc-gen-" + plugin.Name
+}
+
+// EnsureToolchain verifies every pinned tool in Toolchain.Dir, installing it
+// from the artifact cache first when it is missing or does not match its
+// checksum. Any mismatch is fatal: generation never falls back to PATH.
+func (pm *ProtoManager) EnsureToolchain(ctx context.Context) ([]InstalledTool, error) {
+    tc := pm.Config.Toolchain
+    if !tc.locked() {
+        return nil, nil
+    }
+
+    pm.toolchainMu.Lock()
+    defer pm.toolchainMu.Unlock()
+
+    var tools []InstalledTool
+    ensure := func(name string, lock ToolLock) error {
+        tool, err := pm.ensureTool(ctx, name, lock)
+        if err != nil {
+            pm.Logger.Errorf("Toolchain verification failed: %v", err)
+            pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Toolchain verification failed: %v", err)})
+            return err
+        }
+        tools = append(tools, tool)
+        return nil
+    }
+
+    if tc.Protoc != nil {
+        if err := ensure("protoc", *tc.Protoc); err != nil {
+            return nil, err
+        }
+    }
+    for _, name := range sortedKeys(tc.Plugins) {
+        if err := ensure(name, tc.Plugins[name]); err != nil {
+            return nil, err
+        }
+    }
+    return tools, nil
+}
+
+// ensureTool verifies, and if needed installs, one pinned tool.
+func (pm *ProtoManager) ensureTool(ctx context.Context, name string, lock ToolLock) (InstalledTool, error) {
+    tc := pm.Config.Toolchain
+    if lock.SHA256 == "" {
+        return InstalledTool{}, &ToolchainError{Tool: name, Problem: "no sha256 pinned"}
+    }
+    if tc.Dir == "" {
+        return InstalledTool{}, &ToolchainError{Tool: name, Problem: "toolchain.dir is not configured"}
+    }
+
+    path := filepath.Join(tc.Dir, lock.binaryName(name))
+    digest, err := fileDigest(path)
+    if err != nil || !strings.EqualFold(digest, lock.SHA256) {
+        if err == nil {
+            pm.Logger.Warnf("Installed %s has checksum %s, expected %s; reinstalling", name, digest, lock.SHA256)
+        }
+        if err := pm.installTool(name, lock, path); err != nil {
+            return InstalledTool{}, err
+        }
+    }
+
+    version := toolVersion(ctx, path)
+    if lock.Version != "" && !versionMatches(version, lock.Version) {
+        return InstalledTool{}, &ToolchainError{Tool: name, Problem: fmt.Sprintf("'%s' reports version '%s', expected '%s'", path, version, lock.Version)}
+    }
+    return InstalledTool{Name: name, Path: path, Version: version}, nil
+}
+
+// installTool copies the artifact of a pinned tool into place after checking its checksum.
+func (pm *ProtoManager) installTool(name string, lock ToolLock, path string) error {
+    tc := pm.Config.Toolchain
+    if tc.ArtifactCache == "" {
+        return &ToolchainError{Tool: name, Problem: fmt.Sprintf("'%s' is missing or does not match its checksum and toolchain.artifact_cache is not configured", path)}
+    }
+
+    artifact := lock.Artifact
+    if artifact == "" {
+        artifact = lock.binaryName(name) + "-" + lock.Version
+    }
+    src := filepath.Join(tc.ArtifactCache, filepath.FromSlash(artifact))
+
+    digest, err := fileDigest(src)
+    if err != nil {
+        return &ToolchainError{Tool: name, Problem: fmt.Sprintf("artifact '%s' is not available: %v", src, err)}
+    }
+    if !strings.EqualFold(digest, lock.SHA256) {
+        return &ToolchainError{Tool: name, Problem: fmt.Sprintf("artifact '%s' has checksum %s, expected %s", src, digest, lock.SHA256)}
+    }
+
+    if err := copyFile(src, path); err != nil {
+        return &ToolchainError{Tool: name, Problem: fmt.Sprintf("failed to install '%s': %v", path, err)}
+    }
+    if err := os.Chmod(path, 0755); err != nil {
+        return err
+    }
+
+    pm.Logger.Infof("Installed %s from '%s' to '%s'", name, src, path)
+    pm.emitEvent(Event{Type: "ToolInstalled", Message: fmt.Sprintf("Installed %s to '%s'", name, path)})
+    return nil
+}
+
+// versionTokenPattern matches a version number in `--version` output.
+var versionTokenPattern = regexp.MustCompile(`\bv?\d+(?:\.\d+)*(?:[-+][0-9A-Za-z.-]+)?`)
+
+// versionMatches reports whether a version token in output equals want, or
+// starts with want followed by a dot, so "25.1" matches "libprotoc 25.1" and
+// "3.21" matches "3.21.12" but "3.2" does not match "13.21.0". A leading "v"
+// is ignored on both sides.
+func versionMatches(output, want string) bool {
+    want = strings.TrimPrefix(want, "v")
+    for _, token := range versionTokenPattern.FindAllString(output, -1) {
+        token = strings.TrimPrefix(token, "v")
+        if token == want || strings.HasPrefix(token, want+".") {
+            return true
+        }
+    }
+    return false
+}
+
+// lockedGenerator verifies the pinned toolchain before every run and points
+// the backend at the verified binaries.
+type lockedGenerator struct {
+    pm      *ProtoManager
+    backend Generator
+}
+
+// Generate implements Generator.
+func (g *lockedGenerator) Generate(ctx context.Context, req GenerateRequest) error {
+    if _, err := g.pm.EnsureToolchain(ctx); err != nil {
+        return err
+    }
+
+    locked := req
+    locked.Plugins = make([]PluginInvocation, len(req.Plugins))
+    for i, plugin := range req.Plugins {
+        if _, ok := g.pm.Config.Toolchain.Plugins[plugin.Name]; ok {
+            plugin.Binary = g.pm.pluginBinary(plugin)
+        }
+        locked.Plugins[i] = plugin
+    }
+    return g.backend.Generate(ctx, locked)
+}
+
+// backendName names the generation backend for fingerprints and manifests.
+func (pm *ProtoManager) backendName() string {
+    g := pm.CodeGenerator()
+    if locked, ok := g.(*lockedGenerator); ok {
+        g = locked.backend
+    }
+    switch g.(type) {
+    case *ProtocGenerator:
+        return "protoc"
+    case *NativeGenerator:
+        return "native"
+    default:
+        return fmt.Sprintf("%T", g)
+    }
+}
@@ -0,0 +1,52 @@
+// protomanager/toolchain_test.go
+package protomanager
+
+import (
+    "path/filepath"
+    "testing"
+)
+
+func TestVersionMatches(t *testing.T) {
+    tests := []struct {
+        output string
+        want   string
+        match  bool
+    }{
+        {"libprotoc 25.1", "25.1", true},
+        {"libprotoc 3.21.12", "3.21", true},
+        {"libprotoc 3.21.12", "3.21.12", true},
+        {"libprotoc 13.21.0", "3.2", false},
+        {"libprotoc 3.21.0", "3.2", false},
+        {"libprotoc 25.10", "25.1", false},
+        {"protoc-gen-go v1.34.2", "v1.34.2", true},
+        {"protoc-gen-go v1.34.2", "1.34.2", true},
+        {"protoc-gen-go-grpc 1.3.0", "v1.3.0", true},
+        {"protoc-gen-go-grpc 1.3.0-dev", "1.3.0", false},
+        {"protoc-gen-go-grpc 1.3.0-dev", "1.3.0-dev", true},
+        {"unknown", "1.0", false},
+    }
+    for _, tt := range tests {
+        if got := versionMatches(tt.output, tt.want); got != tt.match {
+            t.Errorf("versionMatches(%q, %q) = %v, want %v", tt.output, tt.want, got, tt.match)
+        }
+    }
+}
+
+func TestPluginBinaryPrefersLock(t *testing.T) {
+    pm := newTestProtoManager(t)
+    pm.Config.Toolchain.Dir = "bin"
+    pm.Config.Toolchain.Plugins = map[string]ToolLock{
+        "grpc_python": {Version: "1.62.0", Binary: "grpc_python_plugin"},
+    }
+
+    locked := PluginInvocation{Name: "grpc_python", Binary: "grpc_python_plugin"}
+    if got, want := pm.pluginBinary(locked), filepath.Join("bin", "grpc_python_plugin"); got != want {
+        t.Errorf("locked plugin runs %q, want %q", got, want)
+    }
+    if got := pm.pluginBinary(PluginInvocation{Name: "grpc_cpp", Binary: "grpc_cpp_plugin"}); got != "grpc_cpp_plugin" {
+        t.Errorf("unlocked plugin runs %q, want its own binary", got)
+    }
+    if got := pm.pluginBinary(PluginInvocation{Name: "go"}); got != "protoc-gen-go" {
+        t.Errorf("unlocked plugin runs %q, want protoc-gen-go", got)
+    }
+}
@@ -0,0 +1,231 @@
+// protomanager/vendor.go
+package protomanager
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io/fs"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+)
+
+// vendorManifestFile records what was vendored into the cache and from where.
+const vendorManifestFile = "vendor.json"
+
+// VendorConfig configures the local cache of well-known and third-party protos.
+type VendorConfig struct {
+    // CacheDir is the vendor cache, added as the last include path.
+    CacheDir string `yaml:"cache_dir"`
+    // WellKnownTypesDir is an include directory containing google/protobuf/*.proto.
+    // It defaults to the include directory shipped with protoc.
+    WellKnownTypesDir string `yaml:"well_known_types_dir"`
+    // ThirdParty lists additional proto sources to vendor.
+    ThirdParty []ThirdPartyProtos `yaml:"third_party"`
+}
+
+// ThirdPartyProtos is a third-party proto source copied into the vendor cache.
+type ThirdPartyProtos struct {
+    Name   string   `yaml:"name"`
+    Source string   `yaml:"source"` // Local directory or git repository URL
+    Ref    string   `yaml:"ref"`    // Branch, tag or commit to fetch when Source is a repository
+    Root   string   `yaml:"root"`   // Subdirectory of the source that acts as include root
+    Files  []string `yaml:"files"`  // Patterns matched against import names; empty vendors every .proto file
+}
+
+// VendoredSource records the files vendored from one source.
+type VendoredSource struct {
+    Name   string   `json:"name"`
+    Source string   `json:"source"`
+    Ref    string   `json:"ref,omitempty"`
+    Files  []string `json:"files"`
+}
+
+// Vendor rebuilds the vendor cache from the well-known types and the
+// configured third-party sources, so generation does not depend on whatever
+// happens to be installed alongside protoc.
+func (pm *ProtoManager) Vendor(ctx context.Context) ([]VendoredSource, error) {
+    cfg := pm.Config.Vendor
+    if cfg.CacheDir == "" {
+        return nil, fmt.Errorf("vendor.cache_dir is not configured")
+    }
+
+    pm.Logger.Infof("Vendoring protos into '%s'", cfg.CacheDir)
+
+    // The new cache is built next to the old one, so it can be swapped in atomically.
+    parent := filepath.Dir(filepath.Clean(cfg.CacheDir))
+    if err := os.MkdirAll(parent, os.ModePerm); err != nil {
+        return nil, err
+    }
+    staging, err := os.MkdirTemp(parent, filepath.Base(cfg.CacheDir)+".tmp-*")
+    if err != nil {
+        return nil, err
+    }
+    defer os.RemoveAll(staging)
+    if err := os.Chmod(staging, 0755); err != nil {
+        return nil, err
+    }
+
+    var sources []VendoredSource
+
+    wktDir := cfg.WellKnownTypesDir
+    if wktDir == "" {
+        wktDir = protocIncludeDir()
+    }
+    switch {
+    case wktDir == "" && pm.Config.Generator == "native":
+        // The native generator bundles the well-known types, so protoc is not needed here either.
+        pm.Logger.Warnf("Could not locate the well-known types; the copies bundled with the native generator are used")
+    case wktDir == "":
+        err := fmt.Errorf("could not locate the well-known types; set vendor.well_known_types_dir")
+        pm.Logger.Errorf("Failed to vendor protos: %v", err)
+        pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to vendor protos: %v", err)})
+        return nil, err
+    default:
+        files, err := vendorTree(wktDir, staging, []string{"google/protobuf/*.proto", "google/protobuf/*/*.proto"})
+        if err != nil {
+            return nil, fmt.Errorf("failed to vendor well-known types from '%s': %w", wktDir, err)
+        }
+        sources = append(sources, VendoredSource{Name: "well-known-types", Source: wktDir, Files: files})
+    }
+
+    for _, tp := range cfg.ThirdParty {
+        source, err := pm.vendorSource(ctx, tp, staging)
+        if err != nil {
+            pm.Logger.Errorf("Failed to vendor '%s': %v", tp.Name, err)
+            pm.emitEvent(Event{Type: "Error", Message: fmt.Sprintf("Failed to vendor '%s': %v", tp.Name, err)})
+            return nil, err
+        }
+        sources = append(sources, source)
+    }
+
+    manifest, err := json.MarshalIndent(sources, "", "  ")
+    if err != nil {
+        return nil, err
+    }
+    if err := os.WriteFile(filepath.Join(staging, vendorManifestFile), append(manifest, '\n'), 0644); err != nil {
+        return nil, err
+    }
+
+    if err := replaceDir(staging, cfg.CacheDir); err != nil {
+        return nil, fmt.Errorf("failed to replace vendor cache: %w", err)
+    }
+
+    pm.Logger.Infof("Vendored %d source(s) into '%s'", len(sources), cfg.CacheDir)
+    pm.emitEvent(Event{Type: "ProtosVendored", Message: fmt.Sprintf("Vendored %d source(s) into '%s'", len(sources), cfg.CacheDir)})
+    return sources, nil
+}
+
+// vendorSource copies one third-party source into dst, fetching it first when
+// it is a git repository.
+func (pm *ProtoManager) vendorSource(ctx context.Context, tp ThirdPartyProtos, dst string) (VendoredSource, error) {
+    dir := tp.Source
+    if isRepositoryURL(tp.Source) {
+        clone, err := os.MkdirTemp("", "protomanager-vendor-*")
+        if err != nil {
+            return VendoredSource{}, err
+        }
+        defer os.RemoveAll(clone)
+
+        // Fetching a single revision works for commits as well as branches and tags.
+        ref := tp.Ref
+        if ref == "" {
+            ref = "HEAD"
+        }
+        pm.Logger.Infof("Fetching '%s' at '%s' for vendoring", tp.Source, ref)
+        for _, args := range [][]string{
+            {"init", "--quiet", clone},
+            {"-C", clone, "fetch", "--quiet", "--depth", "1", tp.Source, ref},
+            {"-C", clone, "checkout", "--quiet", "FETCH_HEAD"},
+        } {
+            if output, err := NewCommand(ctx, pm.Config.Timeouts.Git, "git", args...).CombinedOutput(); err != nil {
+                return VendoredSource{}, fmt.Errorf("failed to fetch '%s' at '%s': %v\nOutput: %s", tp.Source, ref, err, string(output))
+            }
+        }
+        dir = clone
+    }
+
+    files, err := vendorTree(filepath.Join(dir, tp.Root), dst, tp.Files)
+    if err != nil {
+        return VendoredSource{}, err
+    }
+    if len(files) == 0 {
+        return VendoredSource{}, fmt.Errorf("no proto files matched in '%s'", tp.Source)
+    }
+    return VendoredSource{Name: tp.Name, Source: tp.Source, Ref: tp.Ref, Files: files}, nil
+}
+
+// replaceDir moves src to dst, atomically exchanging it with an existing
+// dst, which then ends up at src.
+func replaceDir(src, dst string) error {
+    if _, err := os.Stat(dst); os.IsNotExist(err) {
+        return os.Rename(src, dst)
+    }
+    return exchangeDirs(src, dst)
+}
+
+// vendorTree copies the .proto files under root whose import names match one of
+// patterns (all files when patterns is empty) into dst, returning their names.
+func vendorTree(root, dst string, patterns []string) ([]string, error) {
+    var files []string
+    err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if d.IsDir() || filepath.Ext(p) != ".proto" {
+            return nil
+        }
+        rel, err := filepath.Rel(root, p)
+        if err != nil {
+            return err
+        }
+        name := filepath.ToSlash(rel)
+        if !matchesAny(name, patterns) {
+            return nil
+        }
+        files = append(files, name)
+        return copyFile(p, filepath.Join(dst, rel))
+    })
+    return files, err
+}
+
+// matchesAny reports whether name matches one of patterns (see matchGlob);
+// an empty list matches everything.
+func matchesAny(name string, patterns []string) bool {
+    if len(patterns) == 0 {
+        return true
+    }
+    for _, pattern := range patterns {
+        if matchGlob(pattern, name) {
+            return true
+        }
+    }
+    return false
+}
+
+// isRepositoryURL reports whether source refers to a git repository rather than a local directory.
+func isRepositoryURL(source string) bool {
+    return strings.Contains(source, "://") || strings.HasPrefix(source, "git@") || strings.HasSuffix(source, ".git")
+}
+
+// protocIncludeDir returns the include directory shipped with the protoc on
+// PATH, or a common system location, if it contains the well-known types.
+func protocIncludeDir() string {
+    var candidates []string
+    if bin, err := exec.LookPath("protoc"); err == nil {
+        if resolved, err := filepath.EvalSymlinks(bin); err == nil {
+            bin = resolved
+        }
+        candidates = append(candidates, filepath.Join(filepath.Dir(filepath.Dir(bin)), "include"))
+    }
+    candidates = append(candidates, "/usr/local/include", "/usr/include")
+
+    for _, dir := range candidates {
+        if _, err := os.Stat(filepath.Join(dir, "google", "protobuf", "descriptor.proto")); err == nil {
+            return dir
+        }
+    }
+    return ""
+}
@@ -0,0 +1,71 @@
+// protomanager/vendor_test.go
+package protomanager
+
+import (
+    "context"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// TestVendorCommitRef vendors a repository pinned to a commit that is not
+// the tip of any branch, replacing an existing cache.
+func TestVendorCommitRef(t *testing.T) {
+    if _, err := exec.LookPath("git"); err != nil {
+        t.Skip("git is not installed")
+    }
+    dir := t.TempDir()
+    repo := filepath.Join(dir, "repo")
+    git := func(args ...string) string {
+        t.Helper()
+        cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+        output, err := cmd.CombinedOutput()
+        if err != nil {
+            t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+        }
+        return strings.TrimSpace(string(output))
+    }
+    if err := os.MkdirAll(repo, os.ModePerm); err != nil {
+        t.Fatal(err)
+    }
+    git("init", "--quiet")
+    writeProtos(t, repo, map[string]string{"api/a.proto": "syntax = \"proto3\";\n"})
+    git("add", ".")
+    git("commit", "--quiet", "-m", "first")
+    pinned := git("rev-parse", "HEAD")
+    writeProtos(t, repo, map[string]string{"api/b.proto": "syntax = \"proto3\";\n"})
+    git("add", ".")
+    git("commit", "--quiet", "-m", "second")
+
+    pm := newTestProtoManager(t)
+    wkt := filepath.Join(dir, "include")
+    writeProtos(t, wkt, map[string]string{"google/protobuf/empty.proto": "syntax = \"proto3\";\n"})
+    pm.Config.Vendor = VendorConfig{
+        CacheDir:          filepath.Join(dir, "cache"),
+        WellKnownTypesDir: wkt,
+        ThirdParty:        []ThirdPartyProtos{{Name: "api", Source: "file://" + repo, Ref: pinned}},
+    }
+    writeProtos(t, pm.Config.Vendor.CacheDir, map[string]string{"stale/old.proto": "syntax = \"proto3\";\n"})
+
+    sources, err := pm.Vendor(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(sources) != 2 || !reflect.DeepEqual(sources[1].Files, []string{"api/a.proto"}) {
+        t.Errorf("Vendor() = %+v, want api/a.proto from the pinned commit", sources)
+    }
+    files, err := listFiles(pm.Config.Vendor.CacheDir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := map[string]bool{"api/a.proto": true, "google/protobuf/empty.proto": true, vendorManifestFile: true}
+    if !reflect.DeepEqual(files, want) {
+        t.Errorf("vendor cache holds %v, want %v", files, want)
+    }
+    if leftovers, _ := filepath.Glob(pm.Config.Vendor.CacheDir + ".tmp-*"); len(leftovers) != 0 {
+        t.Errorf("staging directories left behind: %v", leftovers)
+    }
+}